@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	activityGistCreated = "gist_created"
+	activityGistUpdated = "gist_updated"
+	activityStar        = "star"
+	activityFork        = "fork"
+)
+
+// recordActivity appends an event to the activity log, events are recorded for private gists as well and filtered when
+// the feed is read so that gists made public later show up
+func recordActivity(tx *gorm.DB, actor, activityType string, gistId uuid.UUID, forkId *uuid.UUID, createdAt time.Time) error {
+	event := models.ActivityEvent{
+		Actor:     actor,
+		Type:      activityType,
+		GistID:    gistId,
+		ForkID:    forkId,
+		CreatedAt: createdAt,
+	}
+
+	result := tx.Create(&event)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return result.Error
+	}
+	return nil
+}
+
+//	@Summary	Get the activity of the users the current user follows on public gists
+//	@Tags		User Operations
+//	@Produce	json
+//	@Param		direction	query		string	false	"asc or desc (default), events are sorted by the time they happened"
+//	@Param		limit		query		int		false	"The number of events to return, between 1 and 100, defaults to 30"
+//	@Param		cursor		query		string	false	"The cursor of the next or previous page, taken from the links"
+//	@Success	200			{object}	models.FeedEventPageWrapper
+//	@Failure	400			{object}	models.ErrorResponseWrapper
+//	@Failure	401			{object}	models.ErrorResponseWrapper
+//	@Failure	403			{object}	models.ErrorResponseWrapper
+//	@Failure	500			{object}	models.ErrorResponseWrapper
+//	@Router		/users/me/feed [get]
+func (uc *UserController) GetFeed(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	page, ok := parsePageRequest(ctx, feedSorts, "created", sortDescending, "activity_events.id")
+	if !ok {
+		return
+	}
+
+	followedUsers := uc.DB.Model(&models.Follow{}).Select("username").Where("followed_by = ?", currentUser.Username)
+	query := uc.DB.Table("activity_events").
+		Select("activity_events.*, gists.username AS gist_owner, gists.name AS gist_name, gists.title AS gist_title").
+		Joins("JOIN gists ON gists.id = activity_events.gist_id").
+		Joins("LEFT JOIN gists AS forks ON forks.id = activity_events.fork_id").
+		Where("activity_events.actor IN (?)", followedUsers).
+		Where("gists.private = ? AND gists.deleted_at IS NULL", false).
+		Where("activity_events.fork_id IS NULL OR (forks.private = ? AND forks.deleted_at IS NULL)", false)
+
+	events := make([]models.FeedEvent, 0)
+	result := page.apply(query).Scan(&events)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	events, links := paginate(ctx, page, events, func(event models.FeedEvent) (interface{}, string) {
+		return event.CreatedAt, event.ID.String()
+	})
+
+	ctx.JSON(http.StatusOK, models.FeedEventPageWrapper{Events: events, Links: links})
+}
@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Trigram indexes cannot narrow down shorter queries
+const minCodeSearchQueryLength = 3
+
+const (
+	defaultCodeSearchContext = 2
+	maxCodeSearchContext     = 10
+)
+
+// Matching lines returned per file, the files still report how many lines match
+const maxCodeSearchMatchesPerFile = 20
+
+// Longer lines (e.g. minified code) are cut in the results
+const maxCodeSearchLineLength = 500
+
+// The time the queries of a code search can take before they are cancelled
+const codeSearchTimeout = "10s"
+
+// Postgres error codes of invalid regular expressions and of queries cancelled by statement_timeout
+const (
+	invalidRegularExpression = "2201B"
+	queryCanceled            = "57014"
+)
+
+// likeEscaper escapes the wildcards of LIKE patterns, backslash is the default escape character of Postgres
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// codeSearchMatcher builds the conditions matching text against the query: a regular expression (POSIX, as supported
+// by Postgres) or a plain substring
+type codeSearchMatcher struct {
+	query         string
+	regex         bool
+	caseSensitive bool
+}
+
+// embeddedOptions matches the options a Postgres regular expression can start with, e.g. (?i)
+var embeddedOptions = regexp.MustCompile(`^\(\?[a-z]*\)`)
+
+// condition returns the SQL condition matching column and its argument, trigram indexes on the column support both
+func (m codeSearchMatcher) condition(column string) (string, interface{}) {
+	return m.conditionOf(column, m.query)
+}
+
+// fileCondition is condition for columns holding whole files: regular expressions are newline-sensitive so that . and
+// bracket expressions stay on a line and ^ and $ match at the start and end of every line, as they do line by line
+func (m codeSearchMatcher) fileCondition(column string) (string, interface{}) {
+	if !m.regex {
+		return m.condition(column)
+	}
+	if embeddedOptions.MatchString(m.query) {
+		return m.conditionOf(column, "(?n"+m.query[len("(?"):])
+	}
+	return m.conditionOf(column, "(?n)"+m.query)
+}
+
+func (m codeSearchMatcher) conditionOf(column, query string) (string, interface{}) {
+	if m.regex {
+		if m.caseSensitive {
+			return column + " ~ ?", query
+		}
+		return column + " ~* ?", query
+	}
+
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	if m.caseSensitive {
+		return column + " LIKE ?", pattern
+	}
+	return column + " ILIKE ?", pattern
+}
+
+type codeLineMatch struct {
+	FileID     uuid.UUID
+	LineNumber int
+	MatchCount int
+}
+
+// truncateLine cuts a line to maxCodeSearchLineLength bytes without splitting a character
+func truncateLine(line string) string {
+	if len(line) <= maxCodeSearchLineLength {
+		return line
+	}
+	end := maxCodeSearchLineLength
+	for end > 0 && !utf8.RuneStart(line[end]) {
+		end--
+	}
+	return line[:end]
+}
+
+// codeSearchFragments groups the matching lines (sorted, 1-based) of a file with contextLines lines around them,
+// overlapping or adjacent fragments are merged
+func codeSearchFragments(content string, matchingLines []int, contextLines int) []models.CodeSearchFragment {
+	lines := strings.Split(content, "\n")
+	isMatch := make(map[int]bool, len(matchingLines))
+	for _, lineNumber := range matchingLines {
+		isMatch[lineNumber] = true
+	}
+
+	fragments := make([]models.CodeSearchFragment, 0)
+	lastLine := 0
+	for _, lineNumber := range matchingLines {
+		start, end := lineNumber-contextLines, lineNumber+contextLines
+		if start < 1 {
+			start = 1
+		}
+		if end > len(lines) {
+			end = len(lines)
+		}
+		if start <= lastLine {
+			start = lastLine + 1
+		}
+
+		// A new fragment starts unless it continues the previous one
+		if len(fragments) == 0 || start > lastLine+1 {
+			fragments = append(fragments, models.CodeSearchFragment{Lines: make([]models.CodeSearchLine, 0)})
+		}
+		fragment := &fragments[len(fragments)-1]
+		for number := start; number <= end; number++ {
+			fragment.Lines = append(fragment.Lines, models.CodeSearchLine{
+				Number:  number,
+				Content: truncateLine(strings.TrimSuffix(lines[number-1], "\r")),
+				Match:   isMatch[number],
+			})
+		}
+		if end > lastLine {
+			lastLine = end
+		}
+	}
+
+	return fragments
+}
+
+//	@Summary		Search the code of the gists visible to the current user with a substring or a regular expression
+//	@Description	Unlike the full-text search symbols are matched as well. Regular expressions use the POSIX syntax of
+//	@Description	PostgreSQL (e.g. func\s+New.*Controller, \y for word boundaries) and are matched line by line. The
+//	@Description	first 20 matching lines of every file are returned with the lines around them.
+//	@Tags			Search
+//	@Produce		json
+//	@Param			q				query		string	true	"The substring or regular expression, at least 3 characters"
+//	@Param			regex			query		bool	false	"Whether q is a regular expression, defaults to false"
+//	@Param			caseSensitive	query		bool	false	"Defaults to false"
+//	@Param			context			query		int		false	"The number of lines around matching lines, between 0 and 10, defaults to 2"
+//	@Param			user			query		string	false	"Only search the gists of this user"
+//	@Param			language		query		string	false	"Only search the files in this language, e.g. Go"
+//	@Param			sort			query		string	false	"created, updated (default) or stars"
+//	@Param			direction		query		string	false	"asc or desc (default)"
+//	@Param			limit			query		int		false	"The number of gists to return, between 1 and 100, defaults to 30"
+//	@Param			cursor			query		string	false	"The cursor of the next or previous page, taken from the links"
+//	@Success		200				{object}	models.CodeSearchResultArrayWrapper
+//	@Failure		400				{object}	models.ErrorResponseWrapper
+//	@Failure		500				{object}	models.ErrorResponseWrapper
+//	@Failure		503				{object}	models.ErrorResponseWrapper
+//	@Router			/search/code [get]
+func (sc *SearchController) SearchCode(ctx *gin.Context) {
+	matcher := codeSearchMatcher{
+		query:         ctx.Query("q"),
+		regex:         ctx.Query("regex") == "true",
+		caseSensitive: ctx.Query("caseSensitive") == "true",
+	}
+	if utf8.RuneCountInString(matcher.query) < minCodeSearchQueryLength {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "q must be at least "+strconv.Itoa(minCodeSearchQueryLength)+" characters")
+		return
+	}
+
+	contextLines := defaultCodeSearchContext
+	if contextParam := ctx.Query("context"); contextParam != "" {
+		parsedContext, err := strconv.Atoi(contextParam)
+		if err != nil || parsedContext < 0 || parsedContext > maxCodeSearchContext {
+			utils.NewErrorResponse(ctx, http.StatusBadRequest, "context must be between 0 and "+strconv.Itoa(maxCodeSearchContext))
+			return
+		}
+		contextLines = parsedContext
+	}
+
+	page, ok := parsePageRequest(ctx, gistSorts, "updated", sortDescending, "gists.id")
+	if !ok {
+		return
+	}
+
+	// matchingFiles builds a new query every time, gorm statements are modified by the methods chained on them
+	contentCondition, contentArg := matcher.fileCondition("gist_contents.content")
+	language := ctx.Query("language")
+	matchingFiles := func(db *gorm.DB, table string) *gorm.DB {
+		files := db.Table(table).Where(contentCondition, contentArg)
+		if language != "" {
+			files = files.Where("LOWER(gist_contents.language) = LOWER(?)", language)
+		}
+		return files
+	}
+
+	var gists []models.Gist
+	var links models.PageLinks
+	var matches []codeLineMatch
+	err := sc.DB.Transaction(func(tx *gorm.DB) error {
+		// Regular expressions can be slow to match, SET LOCAL only applies to this transaction
+		result := tx.Exec("SET LOCAL statement_timeout = '" + codeSearchTimeout + "'")
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// Whole files are narrowed down with the trigram index first, then the gists are filtered on their matching
+		// lines before being paginated so that every gist of a page has results (a file matching a regular expression
+		// across lines can have no matching line)
+		lineCondition, lineArg := matcher.condition("lines.line")
+		matchingLine := tx.Table("regexp_split_to_table(gist_contents.content, E'\\n') AS lines(line)").
+			Select("1").
+			Where(lineCondition, lineArg)
+		query := tx.Where("gists.id IN (?)", matchingFiles(tx, "gist_contents").
+			Select("gist_contents.gist_id").
+			Where("EXISTS (?)", matchingLine))
+		if user := ctx.Query("user"); user != "" {
+			query = query.Where("gists.username = ?", user)
+		}
+
+		result = page.apply(newGistPolicy(ctx, tx).visibleScope(query)).Find(&gists)
+		if result.Error != nil {
+			return result.Error
+		}
+		gists, links = paginate(ctx, page, gists, gistSortValue(page))
+		if len(gists) == 0 {
+			return nil
+		}
+
+		gistIds := make([]uuid.UUID, 0, len(gists))
+		for _, gist := range gists {
+			gistIds = append(gistIds, gist.ID)
+		}
+
+		lineMatches := matchingFiles(tx, "gist_contents, regexp_split_to_table(gist_contents.content, E'\\n') WITH ORDINALITY AS lines(line, number)").
+			Select(`gist_contents.id AS file_id, lines.number AS line_number,
+				ROW_NUMBER() OVER (PARTITION BY gist_contents.id ORDER BY lines.number) AS match_index,
+				COUNT(*) OVER (PARTITION BY gist_contents.id) AS match_count`).
+			Where("gist_contents.gist_id IN ?", gistIds).
+			Where(lineCondition, lineArg)
+
+		return tx.Table("(?) AS matches", lineMatches).
+			Select("file_id, line_number, match_count").
+			Where("match_index <= ?", maxCodeSearchMatchesPerFile).
+			Order("file_id, line_number").
+			Scan(&matches).Error
+	})
+	if respondCodeSearchError(ctx, err) {
+		return
+	}
+
+	matchingLines := make(map[uuid.UUID][]int)
+	matchCounts := make(map[uuid.UUID]int)
+	fileIds := make([]uuid.UUID, 0)
+	for _, match := range matches {
+		if _, exists := matchingLines[match.FileID]; !exists {
+			fileIds = append(fileIds, match.FileID)
+		}
+		matchingLines[match.FileID] = append(matchingLines[match.FileID], match.LineNumber)
+		matchCounts[match.FileID] = match.MatchCount
+	}
+
+	var files []models.GistContent
+	if len(fileIds) != 0 {
+		result := sc.DB.Where("id IN ?", fileIds).Order("position ASC").Find(&files)
+		if respondCodeSearchError(ctx, result.Error) {
+			return
+		}
+	}
+
+	filesOfGist := make(map[uuid.UUID][]models.CodeSearchFile)
+	for _, file := range files {
+		filesOfGist[file.GistID] = append(filesOfGist[file.GistID], models.CodeSearchFile{
+			ID:         file.ID,
+			Filename:   file.Filename,
+			Language:   file.Language,
+			MatchCount: matchCounts[file.ID],
+			Fragments:  codeSearchFragments(file.Content, matchingLines[file.ID], contextLines),
+		})
+	}
+
+	searchResults := make([]models.CodeSearchResult, 0, len(gists))
+	for _, gist := range gists {
+		if len(filesOfGist[gist.ID]) == 0 {
+			continue
+		}
+		searchResults = append(searchResults, models.CodeSearchResult{
+			ID:        gist.ID,
+			Username:  gist.Username,
+			Name:      gist.Name,
+			Title:     gist.Title,
+			Private:   gist.Private,
+			UpdatedAt: gist.UpdatedAt,
+			Files:     filesOfGist[gist.ID],
+		})
+	}
+
+	ctx.JSON(http.StatusOK, models.CodeSearchResultArrayWrapper{Results: searchResults, Links: links})
+}
+
+// respondCodeSearchError writes the error response of a failed query and reports whether there was an error, invalid
+// regular expressions and searches taking too long are only detected by Postgres
+func respondCodeSearchError(ctx *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case invalidRegularExpression:
+			utils.NewErrorResponse(ctx, http.StatusBadRequest, pgErr.Message)
+			return true
+		case queryCanceled:
+			utils.NewErrorResponse(ctx, http.StatusServiceUnavailable, "the search took too long, try a more specific query")
+			return true
+		}
+	}
+
+	zap.L().Error(err.Error())
+	utils.NewErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+	return true
+}
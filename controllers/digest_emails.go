@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/initializers"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	digestOff    = "off"
+	digestDaily  = "daily"
+	digestWeekly = "weekly"
+)
+
+const digestCheckInterval = time.Hour
+
+// Comments are cut to this many characters in the digest
+const digestCommentLength = 200
+
+func digestPeriod(digest string) time.Duration {
+	if digest == digestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+type digestRow struct {
+	Username string
+	GistID   uuid.UUID
+	GistName string
+	Content  string
+}
+
+// StartDigestMailer periodically emails a digest of the activity since the last digest to the users who chose a daily or
+// weekly digest. Digests without any activity are skipped.
+func StartDigestMailer(DB *gorm.DB, config initializers.Config) {
+	go func() {
+		ticker := time.NewTicker(digestCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			sendDueDigests(DB, config)
+			<-ticker.C
+		}
+	}()
+}
+
+func sendDueDigests(DB *gorm.DB, config initializers.Config) {
+	var preferences []models.EmailPreferences
+	result := DB.Find(&preferences, "digest IN ?", []string{digestDaily, digestWeekly})
+	if result.Error != nil {
+		zap.L().Error("could not load digest preferences", zap.Error(result.Error))
+		return
+	}
+
+	now := time.Now()
+	for _, preference := range preferences {
+		if preference.LastDigestAt != nil && now.Sub(*preference.LastDigestAt) < digestPeriod(preference.Digest) {
+			continue
+		}
+
+		err := sendDigest(DB, config, preference, now)
+		if err != nil {
+			zap.L().Error("could not send digest", zap.String("username", preference.Username), zap.Error(err))
+		}
+	}
+}
+
+// sendDigest emails the activity between the last digest of the user and until, the digest is marked as sent even when
+// there was nothing to send
+func sendDigest(DB *gorm.DB, config initializers.Config, preference models.EmailPreferences, until time.Time) error {
+	var user models.User
+	result := DB.First(&user, "username = ?", preference.Username)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if preference.LastDigestAt != nil && user.Verified {
+		digest, err := collectDigest(DB, config, user, *preference.LastDigestAt, until)
+		if err != nil {
+			return err
+		}
+
+		if !digest.IsEmpty() {
+			token, err := utils.SignUnsubscribeToken(config.UnsubscribeSecret, user.Username, emailCategoryDigest)
+			if err != nil {
+				return err
+			}
+			emailData := utils.EmailData{
+				URL:            config.ClientOrigin,
+				FirstName:      user.FirstName,
+				Subject:        "[GitHub-Gist-Clone] Your " + preference.Digest + " activity digest",
+				Message:        "Here is what happened since your last digest.",
+				UnsubscribeURL: config.ServerOrigin + "/api/users/unsubscribe?token=" + url.QueryEscape(token),
+				Digest:         &digest,
+			}
+
+			err = utils.SendEmail(user.Email, &emailData, "digest.html")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	result = DB.Model(&preference).UpdateColumn("last_digest_at", until)
+	return result.Error
+}
+
+func collectDigest(DB *gorm.DB, config initializers.Config, user models.User, since, until time.Time) (utils.DigestData, error) {
+	digest := utils.DigestData{}
+
+	result := DB.Model(&models.Follow{}).
+		Where("username = ? AND created_at > ? AND created_at <= ?", user.Username, since, until).
+		Order("created_at ASC").
+		Pluck("followed_by", &digest.NewFollowers)
+	if result.Error != nil {
+		return digest, result.Error
+	}
+
+	var stars []digestRow
+	result = DB.Table("stars").
+		Select("stars.username, gists.id AS gist_id, gists.name AS gist_name").
+		Joins("JOIN gists ON gists.id = stars.gist_id").
+		Where("gists.username = ? AND gists.deleted_at IS NULL AND stars.username <> ?", user.Username, user.Username).
+		Where("stars.created_at > ? AND stars.created_at <= ?", since, until).
+		Order("stars.created_at ASC").
+		Scan(&stars)
+	if result.Error != nil {
+		return digest, result.Error
+	}
+	digest.Stars = toDigestItems(config, stars)
+
+	var comments []digestRow
+	result = DB.Table("comments").
+		Select("comments.username, gists.id AS gist_id, gists.name AS gist_name, comments.content").
+		Joins("JOIN gists ON gists.id = comments.gist_id").
+		Where("gists.username = ? AND gists.deleted_at IS NULL AND comments.username <> ?", user.Username, user.Username).
+		Where("comments.created_at > ? AND comments.created_at <= ?", since, until).
+		Order("comments.created_at ASC").
+		Scan(&comments)
+	if result.Error != nil {
+		return digest, result.Error
+	}
+	digest.Comments = toDigestItems(config, comments)
+
+	var newGists []models.Gist
+	result = DB.
+		Where("username IN (?)", DB.Model(&models.Follow{}).Select("username").Where("followed_by = ?", user.Username)).
+		Where("created_at > ? AND created_at <= ?", since, until).
+		Order("created_at ASC").
+		Find(&newGists)
+	if result.Error != nil {
+		return digest, result.Error
+	}
+
+	policy := &gistPolicy{db: DB, viewer: &user}
+	gists := make([]digestRow, 0, len(newGists))
+	for _, gist := range policy.filterVisible(newGists) {
+		gists = append(gists, digestRow{Username: gist.Username, GistID: gist.ID, GistName: gist.Name})
+	}
+	digest.NewGists = toDigestItems(config, gists)
+
+	return digest, nil
+}
+
+func toDigestItems(config initializers.Config, rows []digestRow) []utils.DigestItem {
+	items := make([]utils.DigestItem, 0, len(rows))
+	for _, row := range rows {
+		content := []rune(row.Content)
+		if len(content) > digestCommentLength {
+			content = append(content[:digestCommentLength], '…')
+		}
+
+		items = append(items, utils.DigestItem{
+			Username: row.Username,
+			GistName: row.GistName,
+			Content:  string(content),
+			URL:      config.ClientOrigin + "/gists/" + row.GistID.String(),
+		})
+	}
+	return items
+}
@@ -0,0 +1,795 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/initializers"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// How often the edits of a live editing session are saved, a session records a single revision of the gist which is
+// replaced by every save
+const editSessionPersistInterval = 30 * time.Second
+
+// Number of operations kept per file to transform the operations of clients which are behind
+const editHistorySize = 500
+
+const editClientBuffer = 64
+
+const maxEditMessageBytes = 1 << 20
+
+// Same limit as the content column of GistContent
+const maxEditDocumentLength = 10485760
+
+// editDocument is the live content of a file of the gist, revision counts the operations applied since the session
+// started
+type editDocument struct {
+	fileId       uuid.UUID
+	filename     string
+	content      []uint16
+	revision     int
+	historyStart int
+	history      []*utils.TextOperation
+}
+
+type editClient struct {
+	id       string
+	username string
+	send     chan editServerMessage
+	closed   bool
+
+	fileId       *uuid.UUID
+	position     int
+	selectionEnd int
+}
+
+// editSession is the live editing session of a gist, all connected clients edit the same documents. Operations are
+// transformed against the concurrent operations the client had not seen yet (operational transform, compatible with
+// ot.js) so that every client converges to the same content.
+type editSession struct {
+	mu         sync.Mutex
+	gistId     uuid.UUID
+	documents  map[uuid.UUID]*editDocument
+	fileOrder  []uuid.UUID
+	clients    map[string]*editClient
+	dirty      bool
+	lastEditor string
+	done       chan struct{}
+
+	// Serialises the saves so that a failed one marks the session dirty before the next one checks it
+	persistMu sync.Mutex
+
+	// The revision recorded by the first save of the session, the next saves replace it so that the session adds a
+	// single revision and activity entry. Guarded by persistMu.
+	revision *models.GistRevision
+
+	// Set while the session saves its edits after the last client left, joining clients wait for it to be closed.
+	// Guarded by the mutex of the registry.
+	closing chan struct{}
+}
+
+type editSessionRegistry struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*editSession
+
+	// The gists with a session, marked before the session loads the gist. It has its own mutex since REST updates
+	// check it while holding the lock of the gist row, which a starting session waits for with mu held.
+	activeMu sync.Mutex
+	active   map[uuid.UUID]bool
+}
+
+var editSessions = &editSessionRegistry{
+	sessions: make(map[uuid.UUID]*editSession),
+	active:   make(map[uuid.UUID]bool),
+}
+
+type editClientMessage struct {
+	Type         string               `json:"type"`
+	FileID       uuid.UUID            `json:"fileId"`
+	Revision     int                  `json:"revision"`
+	Operation    *utils.TextOperation `json:"operation"`
+	Position     int                  `json:"position"`
+	SelectionEnd int                  `json:"selectionEnd"`
+}
+
+type editServerMessage struct {
+	Type      string               `json:"type"`
+	ClientID  string               `json:"clientId,omitempty"`
+	Username  string               `json:"username,omitempty"`
+	FileID    *uuid.UUID           `json:"fileId,omitempty"`
+	Revision  *int                 `json:"revision,omitempty"`
+	Operation *utils.TextOperation `json:"operation,omitempty"`
+	Files     []editFileState      `json:"files,omitempty"`
+	Clients   []editPresence       `json:"clients,omitempty"`
+	Message   string               `json:"message,omitempty"`
+}
+
+type editFileState struct {
+	FileID   uuid.UUID `json:"fileId"`
+	Filename string    `json:"filename"`
+	Content  string    `json:"content"`
+	Revision int       `json:"revision"`
+}
+
+type editPresence struct {
+	ClientID     string     `json:"clientId"`
+	Username     string     `json:"username"`
+	FileID       *uuid.UUID `json:"fileId,omitempty"`
+	Position     int        `json:"position"`
+	SelectionEnd int        `json:"selectionEnd"`
+}
+
+// isActive reports whether the gist is being edited in a live session. Updates must check it while holding the lock of
+// the gist row, a session starting meanwhile then waits for the update to be committed before loading the gist.
+func (r *editSessionRegistry) isActive(gistId uuid.UUID) bool {
+	r.activeMu.Lock()
+	defer r.activeMu.Unlock()
+
+	return r.active[gistId]
+}
+
+func (r *editSessionRegistry) setActive(gistId uuid.UUID, active bool) {
+	r.activeMu.Lock()
+	defer r.activeMu.Unlock()
+
+	if active {
+		r.active[gistId] = true
+	} else {
+		delete(r.active, gistId)
+	}
+}
+
+// errEditNotAllowed is returned by join when the user cannot edit the gist (anymore)
+var errEditNotAllowed = errors.New("you cannot edit this gist")
+
+// join adds the client of user to the editing session of the gist, the session is started when it does not exist yet
+func (r *editSessionRegistry) join(db *gorm.DB, gistId uuid.UUID, user models.User, client *editClient) (*editSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// A session being closed is saving the last edits, a new session must start from the saved content
+	for {
+		session, exists := r.sessions[gistId]
+		if !exists || session.closing == nil {
+			break
+		}
+		closing := session.closing
+		r.mu.Unlock()
+		<-closing
+		r.mu.Lock()
+	}
+
+	// The permission is checked again under the lock, a collaborator removed meanwhile is either refused here or
+	// already in the session when it gets disconnected
+	var gist models.Gist
+	result := db.Select("id", "username").First(&gist, "id = ?", gistId)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	policy := &gistPolicy{db: db, viewer: &user}
+	if !policy.canEdit(gist) {
+		return nil, errEditNotAllowed
+	}
+
+	session, exists := r.sessions[gistId]
+	if !exists {
+		r.setActive(gistId, true)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return tx.Clauses(clause.Locking{Strength: "SHARE"}).
+				Preload("Files", orderedFiles).
+				First(&gist, "id = ?", gistId).Error
+		})
+		if err != nil {
+			r.setActive(gistId, false)
+			return nil, err
+		}
+
+		session = &editSession{
+			gistId:    gistId,
+			documents: make(map[uuid.UUID]*editDocument),
+			clients:   make(map[string]*editClient),
+			done:      make(chan struct{}),
+		}
+		for _, file := range gist.Files {
+			session.documents[file.ID] = &editDocument{
+				fileId:   file.ID,
+				filename: file.Filename,
+				content:  utils.EncodeText(file.Content),
+			}
+			session.fileOrder = append(session.fileOrder, file.ID)
+		}
+
+		r.sessions[gistId] = session
+		go r.persistPeriodically(db, session)
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	session.clients[client.id] = client
+
+	files := make([]editFileState, 0, len(session.fileOrder))
+	for _, fileId := range session.fileOrder {
+		document := session.documents[fileId]
+		files = append(files, editFileState{
+			FileID:   document.fileId,
+			Filename: document.filename,
+			Content:  utils.DecodeText(document.content),
+			Revision: document.revision,
+		})
+	}
+	session.sendLocked(client, editServerMessage{
+		Type:     "init",
+		ClientID: client.id,
+		Files:    files,
+		Clients:  session.presenceLocked(),
+	})
+	session.broadcastPresenceLocked()
+
+	return session, nil
+}
+
+// leave removes the client from the session, the session is saved and stopped when the last client leaves
+func (r *editSessionRegistry) leave(db *gorm.DB, session *editSession, client *editClient) {
+	session.mu.Lock()
+	session.dropClientLocked(client)
+	empty := len(session.clients) == 0
+	if !empty {
+		session.broadcastPresenceLocked()
+	}
+	session.mu.Unlock()
+
+	if empty {
+		r.close(db, session)
+	}
+}
+
+// close saves and stops the session if it has no clients, the registry is not locked while saving. If saving fails the
+// session is kept with its edits and closing is retried periodically.
+func (r *editSessionRegistry) close(db *gorm.DB, session *editSession) {
+	r.mu.Lock()
+	session.mu.Lock()
+	empty := len(session.clients) == 0
+	session.mu.Unlock()
+	if !empty || session.closing != nil || r.sessions[session.gistId] != session {
+		r.mu.Unlock()
+		return
+	}
+	closing := make(chan struct{})
+	session.closing = closing
+	r.mu.Unlock()
+
+	// The clients are gone, the edits of a gist deleted meanwhile have nobody left to be reported to
+	err := session.persist(db)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session.closing = nil
+	close(closing)
+	if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+		delete(r.sessions, session.gistId)
+		r.setActive(session.gistId, false)
+		close(session.done)
+	}
+}
+
+// disconnect drops the clients of a user from the session of the gist, e.g. when the user is no longer a collaborator.
+// Their connections are closed once the error message is sent. It must be called after the permission of the user was
+// revoked, join checks the permission under the same lock.
+func (r *editSessionRegistry) disconnect(gistId uuid.UUID, username string) {
+	r.mu.Lock()
+	session, exists := r.sessions[gistId]
+	r.mu.Unlock()
+	if !exists {
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	dropped := false
+	for _, client := range session.clients {
+		if client.username == username {
+			session.sendLocked(client, editServerMessage{Type: "error", Message: "you can no longer edit this gist"})
+			session.dropClientLocked(client)
+			dropped = true
+		}
+	}
+	if dropped {
+		session.broadcastPresenceLocked()
+	}
+}
+
+// terminate stops the session without saving it and disconnects its clients with the message
+func (r *editSessionRegistry) terminate(session *editSession, message string) {
+	r.mu.Lock()
+	if r.sessions[session.gistId] == session {
+		delete(r.sessions, session.gistId)
+		r.setActive(session.gistId, false)
+		close(session.done)
+	}
+	r.mu.Unlock()
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	for _, client := range session.clients {
+		session.sendLocked(client, editServerMessage{Type: "error", Message: message})
+		session.dropClientLocked(client)
+	}
+}
+
+func (r *editSessionRegistry) persistPeriodically(db *gorm.DB, session *editSession) {
+	ticker := time.NewTicker(editSessionPersistInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-session.done:
+			return
+		case <-ticker.C:
+			// Sessions left without clients are still there when their final save failed
+			session.mu.Lock()
+			empty := len(session.clients) == 0
+			session.mu.Unlock()
+
+			if empty {
+				r.close(db, session)
+			} else if err := session.persist(db); errors.Is(err, gorm.ErrRecordNotFound) {
+				r.terminate(session, "the gist was deleted, the last edits could not be saved")
+			}
+		}
+	}
+}
+
+// persist saves the current content of the documents when anything changed, the first save records a revision of the
+// gist and the next ones replace it. When saving fails the session stays dirty so that the next save retries, the
+// error is gorm.ErrRecordNotFound when the gist no longer exists.
+func (s *editSession) persist(db *gorm.DB) error {
+	s.persistMu.Lock()
+	defer s.persistMu.Unlock()
+
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	contents := make(map[uuid.UUID]string, len(s.documents))
+	for fileId, document := range s.documents {
+		contents[fileId] = utils.DecodeText(document.content)
+	}
+	author := s.lastEditor
+	s.dirty = false
+	s.mu.Unlock()
+
+	var revision models.GistRevision
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var gist models.Gist
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Files", orderedFiles).
+			First(&gist, "id = ?", s.gistId)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		previousGist := gist
+		files := make([]models.GistContent, len(gist.Files))
+		copy(files, gist.Files)
+		for i := range files {
+			if content, exists := contents[files[i].ID]; exists {
+				files[i].Content = content
+				files[i].Size = len(content)
+			}
+		}
+		gist.Files = files
+		gist.UpdatedAt = time.Now()
+		gist.LastActivityAt = gist.UpdatedAt
+
+		var err error
+		if s.revision != nil {
+			revision, err = amendGistUpdate(tx, previousGist, &gist, *s.revision, author)
+		} else {
+			revision, err = saveGistUpdate(tx, previousGist, &gist, nil, author)
+		}
+		return err
+	})
+	if err != nil {
+		zap.L().Error("could not save live edits", zap.String("gistId", s.gistId.String()), zap.Error(err))
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return err
+	}
+
+	s.revision = &revision
+	return nil
+}
+
+// sendLocked queues a message for the client, clients which do not keep up are disconnected. s.mu must be held.
+func (s *editSession) sendLocked(client *editClient, message editServerMessage) {
+	if client.closed {
+		return
+	}
+	select {
+	case client.send <- message:
+	default:
+		s.dropClientLocked(client)
+	}
+}
+
+// dropClientLocked removes the client and stops its writer, which closes the connection. s.mu must be held.
+func (s *editSession) dropClientLocked(client *editClient) {
+	delete(s.clients, client.id)
+	if !client.closed {
+		client.closed = true
+		close(client.send)
+	}
+}
+
+func (s *editSession) presenceLocked() []editPresence {
+	presence := make([]editPresence, 0, len(s.clients))
+	for _, client := range s.clients {
+		presence = append(presence, editPresence{
+			ClientID:     client.id,
+			Username:     client.username,
+			FileID:       client.fileId,
+			Position:     client.position,
+			SelectionEnd: client.selectionEnd,
+		})
+	}
+	return presence
+}
+
+func (s *editSession) broadcastPresenceLocked() {
+	message := editServerMessage{Type: "presence", Clients: s.presenceLocked()}
+	for _, client := range s.clients {
+		s.sendLocked(client, message)
+	}
+}
+
+func (s *editSession) applyOperation(client *editClient, message editClientMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	document, exists := s.documents[message.FileID]
+	if !exists {
+		return errors.New("file does not exist")
+	}
+	if message.Operation == nil {
+		return errors.New("operation is required")
+	}
+	if message.Revision < document.historyStart || message.Revision > document.revision {
+		return errors.New("revision is too old or unknown, reconnect to get the current content")
+	}
+
+	// Transform the operation against the operations applied since the revision the client based it on
+	operation := message.Operation
+	for _, concurrentOperation := range document.history[message.Revision-document.historyStart:] {
+		transformedOperation, _, err := utils.TransformOperations(operation, concurrentOperation)
+		if err != nil {
+			return err
+		}
+		operation = transformedOperation
+	}
+
+	if operation.TargetLength() > maxEditDocumentLength {
+		return errors.New("the file is too large")
+	}
+	content, err := operation.Apply(document.content)
+	if err != nil {
+		return err
+	}
+
+	document.content = content
+	document.revision++
+	document.history = append(document.history, operation)
+	if len(document.history) > editHistorySize {
+		trimmed := len(document.history) - editHistorySize
+		document.history = document.history[trimmed:]
+		document.historyStart += trimmed
+	}
+
+	for _, otherClient := range s.clients {
+		if otherClient.fileId != nil && *otherClient.fileId == document.fileId {
+			otherClient.position = operation.TransformIndex(otherClient.position)
+			otherClient.selectionEnd = operation.TransformIndex(otherClient.selectionEnd)
+		}
+	}
+
+	s.dirty = true
+	s.lastEditor = client.username
+
+	revision := document.revision
+	s.sendLocked(client, editServerMessage{Type: "ack", FileID: &document.fileId, Revision: &revision})
+	for _, otherClient := range s.clients {
+		if otherClient.id == client.id {
+			continue
+		}
+		s.sendLocked(otherClient, editServerMessage{
+			Type:      "operation",
+			ClientID:  client.id,
+			Username:  client.username,
+			FileID:    &document.fileId,
+			Revision:  &revision,
+			Operation: operation,
+		})
+	}
+
+	return nil
+}
+
+func (s *editSession) moveCursor(client *editClient, message editClientMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	document, exists := s.documents[message.FileID]
+	if !exists {
+		return errors.New("file does not exist")
+	}
+
+	clamp := func(position int) int {
+		if position < 0 {
+			return 0
+		}
+		if position > len(document.content) {
+			return len(document.content)
+		}
+		return position
+	}
+
+	client.fileId = &document.fileId
+	client.position = clamp(message.Position)
+	client.selectionEnd = clamp(message.SelectionEnd)
+	s.broadcastPresenceLocked()
+
+	return nil
+}
+
+func (s *editSession) sendError(client *editClient, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sendLocked(client, editServerMessage{Type: "error", Message: err.Error()})
+}
+
+//	@Summary		Edit the content of a gist together with other users over a WebSocket
+//	@Description	Only the owner and the invited collaborators can connect. The client receives an "init" message with
+//	@Description	the files and their revision, then sends "operation" messages (fileId, revision, operation in the ot.js
+//	@Description	format) and "cursor" messages (fileId, position, selectionEnd). The server answers operations with
+//	@Description	"ack" and forwards the transformed operations of others as "operation" messages, the connected users
+//	@Description	and their cursors are sent as "presence" messages. Edits are saved every 30 seconds and when the last
+//	@Description	user disconnects, a session records a single revision. If the gist is deleted meanwhile the clients
+//	@Description	receive an "error" message and are disconnected.
+//	@Tags			Gist Operations
+//	@Param			gistId	path	string	true	"The ID of the gist to edit"
+//	@Success		101
+//	@Failure		400	{object}	models.ErrorResponseWrapper
+//	@Failure		401	{object}	models.ErrorResponseWrapper
+//	@Failure		403	{object}	models.ErrorResponseWrapper
+//	@Failure		404	{object}	models.ErrorResponseWrapper
+//	@Router			/gists/{gistId}/edit [get]
+func (gc *GistController) EditGistLive(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	gistId := ctx.Params.ByName("gistId")
+
+	policy := newGistPolicy(ctx, gc.DB)
+	gist, ok := policy.loadVisibleGist(ctx, gc.DB, gistId)
+	if !ok {
+		return
+	}
+
+	if !policy.canEdit(gist) {
+		utils.NewErrorResponse(ctx, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	config, err := initializers.LoadConfig(os.Getenv("API_ENV_CONFIG_PATH"))
+	if err != nil {
+		zap.L().Error(err.Error())
+		utils.SomethingBadHappened(ctx)
+		return
+	}
+
+	server := websocket.Server{
+		// The access token is usually sent as a cookie, browsers on other sites must not be able to use it
+		Handshake: func(wsConfig *websocket.Config, request *http.Request) error {
+			origin := request.Header.Get("Origin")
+			if origin != "" && origin != config.ClientOrigin && origin != "http://localhost:"+config.ServerPort {
+				return errors.New("origin not allowed")
+			}
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			gc.serveEditSession(ws, gist, currentUser)
+		},
+	}
+	server.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+func (gc *GistController) serveEditSession(ws *websocket.Conn, gist models.Gist, currentUser models.User) {
+	defer ws.Close()
+	ws.MaxPayloadBytes = maxEditMessageBytes
+
+	client := &editClient{
+		id:       uuid.New().String(),
+		username: currentUser.Username,
+		send:     make(chan editServerMessage, editClientBuffer),
+	}
+
+	// The writer closes the connection once the client is dropped, which stops the reader below
+	go func() {
+		for message := range client.send {
+			if err := websocket.JSON.Send(ws, message); err != nil {
+				break
+			}
+		}
+		ws.Close()
+	}()
+
+	session, err := editSessions.join(gc.DB, gist.ID, currentUser, client)
+	if err != nil {
+		message := "could not join the editing session"
+		switch {
+		case errors.Is(err, errEditNotAllowed):
+			message = err.Error()
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			message = "gist does not exist"
+		default:
+			zap.L().Error(err.Error())
+		}
+		client.send <- editServerMessage{Type: "error", Message: message}
+		close(client.send)
+		return
+	}
+	defer editSessions.leave(gc.DB, session, client)
+
+	for {
+		var message editClientMessage
+		if err := websocket.JSON.Receive(ws, &message); err != nil {
+			return
+		}
+
+		switch message.Type {
+		case "operation":
+			err = session.applyOperation(client, message)
+		case "cursor":
+			err = session.moveCursor(client, message)
+		default:
+			err = errors.New("unknown message type: " + message.Type)
+		}
+		if err != nil {
+			session.sendError(client, err)
+		}
+	}
+}
+
+//	@Summary	Invite a user to edit a gist of the current user in live editing sessions
+//	@Tags		User Operations
+//	@Produce	json
+//	@Param		gistId		path		string	true	"The ID of the gist"
+//	@Param		username	path		string	true	"The username of the user to invite"
+//	@Success	200			{object}	models.SuccessResponseWrapper
+//	@Failure	400			{object}	models.ErrorResponseWrapper
+//	@Failure	401			{object}	models.ErrorResponseWrapper
+//	@Failure	403			{object}	models.ErrorResponseWrapper
+//	@Failure	404			{object}	models.ErrorResponseWrapper
+//	@Router		/users/gists/{gistId}/collaborators/{username} [patch]
+func (uc *UserController) AddGistCollaborator(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	gistId := ctx.Params.ByName("gistId")
+	username := ctx.Params.ByName("username")
+
+	gist, ok := uc.loadOwnGist(ctx, currentUser, gistId)
+	if !ok {
+		return
+	}
+
+	if username == currentUser.Username {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "You cannot invite yourself to your own gist")
+		return
+	}
+
+	var user models.User
+	result := uc.DB.First(&user, "username = ?", username)
+	if result.Error != nil {
+		utils.NewErrorResponse(ctx, http.StatusNotFound, "user does not exist")
+		return
+	}
+
+	collaborator := models.GistCollaborator{
+		GistID:   gist.ID,
+		Username: user.Username,
+	}
+	result = uc.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&collaborator)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, result.Error.Error())
+		return
+	}
+
+	utils.NewSuccessResponse(ctx, http.StatusOK, "successfully invited collaborator")
+}
+
+//	@Summary	Remove a collaborator from a gist of the current user
+//	@Tags		User Operations
+//	@Produce	json
+//	@Param		gistId		path		string	true	"The ID of the gist"
+//	@Param		username	path		string	true	"The username of the collaborator"
+//	@Success	200			{object}	models.SuccessResponseWrapper
+//	@Failure	400			{object}	models.ErrorResponseWrapper
+//	@Failure	401			{object}	models.ErrorResponseWrapper
+//	@Failure	403			{object}	models.ErrorResponseWrapper
+//	@Failure	404			{object}	models.ErrorResponseWrapper
+//	@Router		/users/gists/{gistId}/collaborators/{username} [delete]
+func (uc *UserController) RemoveGistCollaborator(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	gistId := ctx.Params.ByName("gistId")
+	username := ctx.Params.ByName("username")
+
+	gist, ok := uc.loadOwnGist(ctx, currentUser, gistId)
+	if !ok {
+		return
+	}
+
+	collaboratorToDelete := models.GistCollaborator{
+		GistID:   gist.ID,
+		Username: username,
+	}
+	result := uc.DB.Delete(&collaboratorToDelete)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, result.Error.Error())
+		return
+	}
+	editSessions.disconnect(gist.ID, username)
+
+	utils.NewSuccessResponse(ctx, http.StatusOK, "successfully removed collaborator")
+}
+
+//	@Summary	Get the collaborators of a gist of the current user
+//	@Tags		User Operations
+//	@Produce	json
+//	@Param		gistId	path		string	true	"The ID of the gist"
+//	@Success	200		{object}	models.StringArrayWrapper
+//	@Failure	400		{object}	models.ErrorResponseWrapper
+//	@Failure	401		{object}	models.ErrorResponseWrapper
+//	@Failure	403		{object}	models.ErrorResponseWrapper
+//	@Failure	404		{object}	models.ErrorResponseWrapper
+//	@Failure	500		{object}	models.ErrorResponseWrapper
+//	@Router		/users/gists/{gistId}/collaborators [get]
+func (uc *UserController) GetGistCollaborators(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	gistId := ctx.Params.ByName("gistId")
+
+	gist, ok := uc.loadOwnGist(ctx, currentUser, gistId)
+	if !ok {
+		return
+	}
+
+	var collaborators []models.GistCollaborator
+	result := uc.DB.Find(&collaborators, "gist_id = ?", gist.ID)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	usernames := make([]string, 0, len(collaborators))
+	for _, collaborator := range collaborators {
+		usernames = append(usernames, collaborator.Username)
+	}
+
+	ctx.JSON(http.StatusOK, models.StringArrayWrapper{StringArray: usernames})
+}
@@ -2,6 +2,9 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
 	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
@@ -24,102 +27,595 @@ func NewGistController(DB *gorm.DB) GistController {
 //	@Summary	Get the gist by gist id, DOES NOT load gist comments
 //	@Tags		Gist Operations
 //	@Produce	json
-//	@Param		gistId	path		string	true	"The ID of the gist"
-//	@Success	200		{object}	models.GistWithoutCommentsWrapper
-//	@Failure	404		{object}	models.ErrorResponseWrapper
-//	@Failure	400		{object}	models.ErrorResponseWrapper
+//	@Param		gistId				path		string	true	"The ID of the gist"
+//	@Param		If-None-Match		header		string	false	"The ETag of the cached response"
+//	@Param		If-Modified-Since	header		string	false	"The Last-Modified date of the cached response"
+//	@Success	200					{object}	models.GistWithoutCommentsWrapper
+//	@Header		200					{string}	ETag	"The version of the gist, send it as If-Match when updating the gist"
+//	@Success	304					"The cached response is still current"
+//	@Failure	404					{object}	models.ErrorResponseWrapper
+//	@Failure	400					{object}	models.ErrorResponseWrapper
 //	@Router		/gists/{gistId} [get]
 func (gc *GistController) GetGistById(ctx *gin.Context) {
 	gistId := ctx.Params.ByName("gistId")
 
-	gistIdParsed, err := uuid.Parse(gistId)
+	gist, ok := newGistPolicy(ctx, gc.DB).loadVisibleGist(ctx, gc.DB.Preload("Files", orderedFiles), gistId)
+	if !ok {
+		return
+	}
+
+	gistWithoutComments := gistWithDetails(gc.DB, gist)
+
+	utils.SetCacheControl(ctx, isPublicResponse(ctx))
+	if utils.NotModified(ctx, gistETag(gistWithoutComments), gist.LastActivityAt) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.GistWithoutCommentsWrapper{
+		Gist: gistWithoutComments,
+	})
+}
+
+//	@Summary		Get the comments of a gist in thread order, every reply follows its parent and carries its depth
+//	@Description	The top level comments are paginated, every page contains the whole threads of its comments.
+//	@Tags			Gist Operations
+//	@Produce		json
+//	@Param			gistId				path		string	true	"The ID of the gist"
+//	@Param			sort				query		string	false	"created (default) or updated, sorts the top level comments"
+//	@Param			direction			query		string	false	"asc (default) or desc"
+//	@Param			limit				query		int		false	"The number of threads to return, between 1 and 100, defaults to 30"
+//	@Param			cursor				query		string	false	"The cursor of the next or previous page, taken from the links"
+//	@Param			If-None-Match		header		string	false	"The ETag of the cached response"
+//	@Param			If-Modified-Since	header		string	false	"The Last-Modified date of the cached response"
+//	@Success		200					{object}	models.CommentPageWrapper
+//	@Success		304					"The cached response is still current"
+//	@Failure		404					{object}	models.ErrorResponseWrapper
+//	@Failure		400					{object}	models.ErrorResponseWrapper
+//	@Router			/gists/{gistId}/comments [get]
+func (gc *GistController) GetGistComments(ctx *gin.Context) {
+	gistId := ctx.Params.ByName("gistId")
+
+	page, ok := parsePageRequest(ctx, commentSorts, "created", sortAscending, "comments.comment_id")
+	if !ok {
+		return
+	}
+
+	gist, ok := newGistPolicy(ctx, gc.DB).loadVisibleGist(ctx, gc.DB, gistId)
+	if !ok {
+		return
+	}
+
+	// Replies whose parent does not exist anymore are top level comments, like in threadComments
+	gistComments := gc.DB.Model(&models.Comment{}).Select("comment_id").Where("gist_id = ?", gist.ID)
+	var topLevelComments []models.Comment
+	query := gc.DB.
+		Where("comments.gist_id = ?", gist.ID).
+		Where("comments.parent_id IS NULL OR comments.parent_id NOT IN (?)", gistComments)
+	result := page.apply(query).Find(&topLevelComments)
+	if result.Error != nil {
+		utils.NewErrorResponse(ctx, http.StatusNotFound, "gist does not exist")
+		return
+	}
+
+	topLevelComments, links := paginate(ctx, page, topLevelComments, func(comment models.Comment) (interface{}, string) {
+		if page.sortName == "updated" {
+			return comment.UpdatedAt, comment.CommentID.String()
+		}
+		return comment.CreatedAt, comment.CommentID.String()
+	})
+
+	comments, err := loadCommentThreads(gc.DB, topLevelComments)
 	if err != nil {
-		utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid gist id")
 		zap.L().Error(err.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	var gist models.Gist
-	result := gc.DB.
-		Preload("GistContent").
-		First(&gist, "id = ?", gistIdParsed)
+	threadedComments := threadComments(comments)
+	withCommentReactions(gc.DB, threadedComments)
+
+	// Every change of the comments or of their reactions is an activity on the gist
+	utils.SetCacheControl(ctx, isPublicResponse(ctx))
+	if utils.NotModified(ctx, utils.NewETag(commentsETag(threadedComments), links.Next, links.Prev), gist.LastActivityAt) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.CommentPageWrapper{Comments: threadedComments, Links: links})
+}
+
+//	@Summary	Get the review comments of a gist grouped by the lines they are anchored to
+//	@Tags		Gist Operations
+//	@Produce	json
+//	@Param		gistId		path		string	true	"The ID of the gist"
+//	@Param		filename	query		string	false	"Only return the comments on this file"
+//	@Param		outdated	query		bool	false	"Whether to include outdated comments, defaults to true"
+//	@Success	200			{object}	models.LineCommentGroupArrayWrapper
+//	@Failure	400			{object}	models.ErrorResponseWrapper
+//	@Failure	404			{object}	models.ErrorResponseWrapper
+//	@Failure	500			{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/line-comments [get]
+func (gc *GistController) GetGistLineComments(ctx *gin.Context) {
+	gistId := ctx.Params.ByName("gistId")
+
+	gist, ok := newGistPolicy(ctx, gc.DB).loadVisibleGist(ctx, gc.DB, gistId)
+	if !ok {
+		return
+	}
+
+	var comments []models.Comment
+	result := gc.DB.Order("created_at ASC").Find(&comments, "gist_id = ?", gist.ID)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	withCommentReactions(gc.DB, comments)
+
+	filename := ctx.Query("filename")
+	includeOutdated := ctx.Query("outdated") != "false"
+
+	groups := make([]models.LineCommentGroup, 0)
+	for _, group := range groupLineComments(comments) {
+		if filename != "" && group.Filename != filename {
+			continue
+		}
+		if group.Outdated && !includeOutdated {
+			continue
+		}
+		groups = append(groups, group)
+	}
+
+	ctx.JSON(http.StatusOK, models.LineCommentGroupArrayWrapper{Groups: groups})
+}
+
+//	@Summary	Get the stargazers of a gist
+//	@Tags		Gist Operations
+//	@Produce	json
+//	@Param		gistId		path		string	true	"The ID of the gist"
+//	@Param		direction	query		string	false	"asc or desc (default), stargazers are sorted by the time they starred"
+//	@Param		limit		query		int		false	"The number of stargazers to return, between 1 and 100, defaults to 30"
+//	@Param		cursor		query		string	false	"The cursor of the next or previous page, taken from the links"
+//	@Success	200			{object}	models.StringPageWrapper
+//	@Failure	400			{object}	models.ErrorResponseWrapper
+//	@Failure	404			{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/stargazers [get]
+func (gc *GistController) GetGistStargazers(ctx *gin.Context) {
+	gistId := ctx.Params.ByName("gistId")
+
+	page, ok := parsePageRequest(ctx, starSorts, "created", sortDescending, "stars.username")
+	if !ok {
+		return
+	}
+
+	gist, ok := newGistPolicy(ctx, gc.DB).loadVisibleGist(ctx, gc.DB, gistId)
+	if !ok {
+		return
+	}
+
+	var stars []models.Star
+	result := page.apply(gc.DB.Where("stars.gist_id = ?", gist.ID)).Find(&stars)
 	if result.Error != nil {
 		utils.NewErrorResponse(ctx, http.StatusNotFound, "gist does not exist")
 		return
 	}
 
-	ctx.JSON(http.StatusOK, models.GistWithoutCommentsWrapper{
-		Gist: models.GistWithoutComments{
-			Username:    gist.Username,
-			StarCount:   gist.StarCount,
-			ID:          gist.ID,
-			Private:     gist.Private,
-			GistContent: gist.GistContent,
-			Name:        gist.Name,
-			Title:       gist.Title,
-			CreatedAt:   gist.CreatedAt,
-			UpdatedAt:   gist.UpdatedAt,
-		},
+	stars, links := paginate(ctx, page, stars, func(star models.Star) (interface{}, string) {
+		return star.CreatedAt, star.Username
 	})
+
+	stargazers := make([]string, 0, len(stars))
+	for _, star := range stars {
+		stargazers = append(stargazers, star.Username)
+	}
+
+	ctx.JSON(http.StatusOK, models.StringPageWrapper{StringArray: stargazers, Links: links})
 }
 
-//	@Summary	Get the comments of a gist
+//	@Summary	Get the revision history of a gist, newest first. DOES NOT load the file contents
 //	@Tags		Gist Operations
 //	@Produce	json
 //	@Param		gistId	path		string	true	"The ID of the gist"
-//	@Success	200		{object}	models.CommentArrayWrapper
+//	@Success	200		{object}	models.GistRevisionSummaryArrayWrapper
+//	@Failure	400		{object}	models.ErrorResponseWrapper
 //	@Failure	404		{object}	models.ErrorResponseWrapper
+//	@Failure	500		{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/revisions [get]
+func (gc *GistController) GetGistRevisions(ctx *gin.Context) {
+	gistId := ctx.Params.ByName("gistId")
+
+	gist, ok := newGistPolicy(ctx, gc.DB).loadVisibleGist(ctx, gc.DB, gistId)
+	if !ok {
+		return
+	}
+
+	var revisions []models.GistRevision
+	result := gc.DB.Order("revision DESC").Find(&revisions, "gist_id = ?", gist.ID)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	summaries := make([]models.GistRevisionSummary, 0, len(revisions))
+	for _, revision := range revisions {
+		summaries = append(summaries, models.GistRevisionSummary{
+			Revision:  revision.Revision,
+			Author:    revision.Author,
+			Name:      revision.Name,
+			Title:     revision.Title,
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, models.GistRevisionSummaryArrayWrapper{Revisions: summaries})
+}
+
+//	@Summary	Get a single revision of a gist along with the snapshot of its files
+//	@Tags		Gist Operations
+//	@Produce	json
+//	@Param		gistId	path		string	true	"The ID of the gist"
+//	@Param		rev		path		int		true	"The revision number"
+//	@Success	200		{object}	models.GistRevisionWrapper
 //	@Failure	400		{object}	models.ErrorResponseWrapper
-//	@Router		/gists/{gistId}/comments [get]
-func (gc *GistController) GetGistComments(ctx *gin.Context) {
+//	@Failure	404		{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/revisions/{rev} [get]
+func (gc *GistController) GetGistRevision(ctx *gin.Context) {
 	gistId := ctx.Params.ByName("gistId")
 
-	gistIdParsed, err := uuid.Parse(gistId)
-	if err != nil {
-		utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid gist id")
-		zap.L().Error(err.Error())
+	gist, ok := newGistPolicy(ctx, gc.DB).loadVisibleGist(ctx, gc.DB, gistId)
+	if !ok {
 		return
 	}
 
-	var comments []models.Comment
-	result := gc.DB.Find(&comments, "gist_id = ?", gistIdParsed)
+	rev, err := strconv.Atoi(ctx.Params.ByName("rev"))
+	if err != nil || rev < 1 {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid revision")
+		return
+	}
+
+	var revision models.GistRevision
+	result := gc.DB.
+		Preload("Files", orderedFiles).
+		First(&revision, "gist_id = ? AND revision = ?", gist.ID, rev)
 	if result.Error != nil {
-		utils.NewErrorResponse(ctx, http.StatusNotFound, "gist does not exist")
+		utils.NewErrorResponse(ctx, http.StatusNotFound, "revision does not exist")
 		return
 	}
 
-	ctx.JSON(http.StatusOK, models.CommentArrayWrapper{Comments: comments})
+	ctx.JSON(http.StatusOK, models.GistRevisionWrapper{Revision: revision})
 }
 
-//	@Summary	Get the stargazers of a gist
+//	@Summary	Compare two revisions of a gist
+//	@Description	The revision range is of the form `fromRev...toRev`. Pass `format=unified` to get a plain unified diff
+//	@Tags			Gist Operations
+//	@Produce		json
+//	@Produce		plain
+//	@Param			gistId			path		string	true	"The ID of the gist"
+//	@Param			revisionRange	path		string	true	"The revisions to compare, e.g. 1...3"
+//	@Param			format			query		string	false	"Response format, json (default) or unified"
+//	@Success		200				{object}	models.GistComparisonWrapper
+//	@Failure		400				{object}	models.ErrorResponseWrapper
+//	@Failure		404				{object}	models.ErrorResponseWrapper
+//	@Router			/gists/{gistId}/compare/{revisionRange} [get]
+func (gc *GistController) CompareGistRevisions(ctx *gin.Context) {
+	gistId := ctx.Params.ByName("gistId")
+
+	gist, ok := newGistPolicy(ctx, gc.DB).loadVisibleGist(ctx, gc.DB, gistId)
+	if !ok {
+		return
+	}
+
+	revisionRange := strings.Split(ctx.Params.ByName("revisionRange"), "...")
+	if len(revisionRange) != 2 {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "revision range must be of the form fromRev...toRev")
+		return
+	}
+
+	fromRev, fromErr := strconv.Atoi(revisionRange[0])
+	toRev, toErr := strconv.Atoi(revisionRange[1])
+	if fromErr != nil || toErr != nil || fromRev < 1 || toRev < 1 {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid revision")
+		return
+	}
+
+	var fromRevision, toRevision models.GistRevision
+	result := gc.DB.
+		Preload("Files", orderedFiles).
+		First(&fromRevision, "gist_id = ? AND revision = ?", gist.ID, fromRev)
+	if result.Error != nil {
+		utils.NewErrorResponse(ctx, http.StatusNotFound, "revision "+revisionRange[0]+" does not exist")
+		return
+	}
+	result = gc.DB.
+		Preload("Files", orderedFiles).
+		First(&toRevision, "gist_id = ? AND revision = ?", gist.ID, toRev)
+	if result.Error != nil {
+		utils.NewErrorResponse(ctx, http.StatusNotFound, "revision "+revisionRange[1]+" does not exist")
+		return
+	}
+
+	comparison := compareGistRevisions(fromRevision, toRevision)
+
+	if ctx.Query("format") == "unified" {
+		ctx.Data(http.StatusOK, "text/x-diff; charset=utf-8", []byte(comparison.Unified))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.GistComparisonWrapper{Comparison: comparison})
+}
+
+//	@Summary	Fork a gist into the account of the current user
+//	@Tags		Gist Operations
+//	@Produce	json
+//	@Param		gistId	path		string	true	"The ID of the gist to fork"
+//	@Success	201		{object}	models.GistWithoutCommentsWrapper
+//	@Failure	400		{object}	models.ErrorResponseWrapper
+//	@Failure	401		{object}	models.ErrorResponseWrapper
+//	@Failure	403		{object}	models.ErrorResponseWrapper
+//	@Failure	404		{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/fork [post]
+func (gc *GistController) ForkGist(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	gistId := ctx.Params.ByName("gistId")
+
+	gist, ok := newGistPolicy(ctx, gc.DB).loadVisibleGist(ctx, gc.DB.Preload("Files", orderedFiles), gistId)
+	if !ok {
+		return
+	}
+
+	if gist.Username == currentUser.Username {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "You cannot fork your own gist")
+		return
+	}
+
+	now := time.Now()
+
+	files := make([]models.GistContent, 0, len(gist.Files))
+	for _, file := range gist.Files {
+		files = append(files, newGistFile(file.Filename, file.Content, file.Position))
+	}
+
+	forkedGist := models.Gist{
+		Username:   currentUser.Username,
+		ForkedFrom: &gist.ID,
+		Private:    gist.Private,
+		Files:      files,
+		Name:       forkGistName(gist.Name, currentUser.Gists),
+		Title:          gist.Title,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		LastActivityAt: now,
+	}
+
+	err := gc.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Session(&gorm.Session{FullSaveAssociations: true}).Create(&forkedGist)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+
+		result = tx.Model(&gist).UpdateColumns(map[string]interface{}{
+			"fork_count":       gorm.Expr("fork_count + 1"),
+			"last_activity_at": now,
+		})
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+
+		var tags []string
+		result = tx.Model(&models.GistTag{}).Where("gist_id = ?", gist.ID).Pluck("tag", &tags)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+		err := replaceGistTags(tx, forkedGist.ID, tags)
+		if err != nil {
+			return err
+		}
+
+		_, err = recordGistRevision(tx, forkedGist, currentUser.Username, now)
+		if err != nil {
+			zap.L().Error(err.Error())
+			return err
+		}
+
+		return recordActivity(tx, currentUser.Username, activityFork, gist.ID, &forkedGist.ID, now)
+	})
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.GistWithoutCommentsWrapper{
+		Gist: gistWithDetails(gc.DB, forkedGist),
+	})
+}
+
+//	@Summary	Get the forks of a gist visible to the current user, DOES NOT load the gist comments
 //	@Tags		Gist Operations
 //	@Produce	json
 //	@Param		gistId	path		string	true	"The ID of the gist"
-//	@Success	200		{object}	models.StringArrayWrapper
+//	@Success	200		{object}	models.GistWithoutCommentsArrayWrapper
 //	@Failure	400		{object}	models.ErrorResponseWrapper
 //	@Failure	404		{object}	models.ErrorResponseWrapper
-//	@Router		/gists/{gistId}/stargazers [get]
-func (gc *GistController) GetGistStargazers(ctx *gin.Context) {
+//	@Failure	500		{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/forks [get]
+func (gc *GistController) GetGistForks(ctx *gin.Context) {
 	gistId := ctx.Params.ByName("gistId")
 
-	parsedGistId, err := uuid.Parse(gistId)
+	policy := newGistPolicy(ctx, gc.DB)
+	gist, ok := policy.loadVisibleGist(ctx, gc.DB, gistId)
+	if !ok {
+		return
+	}
+
+	var forks []models.Gist
+	result := gc.DB.
+		Preload("Files", orderedFiles).
+		Order("created_at ASC").
+		Find(&forks, "forked_from = ?", gist.ID)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	gists := make([]models.GistWithoutComments, 0, len(forks))
+	for _, fork := range policy.filterVisible(forks) {
+		gists = append(gists, toGistWithoutComments(fork))
+	}
+	withGistDetails(gc.DB, gists)
+
+	ctx.JSON(http.StatusOK, models.GistWithoutCommentsArrayWrapper{Gists: gists})
+}
+
+//	@Summary	Edit a comment on a gist, only the author of the comment can edit it
+//	@Tags		Gist Operations
+//	@Accept		json
+//	@Produce	json
+//	@Param		gistId				path		string						true	"The ID of the gist"
+//	@Param		commentId			path		string						true	"The ID of the comment"
+//	@Param		UpdateCommentInput	body		models.UpdateCommentRequest	true	"The Input for editing comment"
+//	@Success	200					{object}	models.CommentWrapper
+//	@Failure	400					{object}	models.ErrorResponseWrapper
+//	@Failure	401					{object}	models.ErrorResponseWrapper
+//	@Failure	403					{object}	models.ErrorResponseWrapper
+//	@Failure	404					{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/comments/{commentId} [patch]
+func (gc *GistController) UpdateComment(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	var payload *models.UpdateCommentRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	gist, comment, ok := gc.loadGistComment(ctx)
+	if !ok {
+		return
+	}
+
+	if comment.Username != currentUser.Username {
+		utils.NewErrorResponse(ctx, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	comment.Content = payload.Content
+	comment.Edited = true
+	comment.UpdatedAt = time.Now()
+
+	err := gc.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Save(&comment)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+
+		_, err := syncCommentMentions(tx, gist, comment)
+		if err != nil {
+			return err
+		}
+
+		return touchGist(tx, gist.ID)
+	})
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	depth, err := commentDepth(gc.DB, comment)
 	if err != nil {
 		zap.L().Error(err.Error())
-		utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid gist id")
+	}
+	comment.Depth = depth
+
+	comment = commentWithReactions(gc.DB, comment)
+	gistEvents.publish(gist.ID, gistEventCommentEdited, comment)
+	ctx.JSON(http.StatusOK, models.CommentWrapper{Comment: comment})
+}
+
+//	@Summary	Delete a comment on a gist, the author of the comment and the owner of the gist can delete it
+//	@Tags		Gist Operations
+//	@Param		gistId		path	string	true	"The ID of the gist"
+//	@Param		commentId	path	string	true	"The ID of the comment"
+//	@Success	204
+//	@Failure	400	{object}	models.ErrorResponseWrapper
+//	@Failure	401	{object}	models.ErrorResponseWrapper
+//	@Failure	403	{object}	models.ErrorResponseWrapper
+//	@Failure	404	{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/comments/{commentId} [delete]
+func (gc *GistController) DeleteComment(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	gist, comment, ok := gc.loadGistComment(ctx)
+	if !ok {
 		return
 	}
 
-	var stars []models.Star
-	result := gc.DB.Find(&stars, "gist_id = ?", parsedGistId)
-	if result.Error != nil {
-		utils.NewErrorResponse(ctx, http.StatusNotFound, "gist does not exist")
+	if comment.Username != currentUser.Username && gist.Username != currentUser.Username {
+		utils.NewErrorResponse(ctx, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
-	var stargazers []string
-	for _, star := range stars {
-		stargazers = append(stargazers, star.Username)
+	err := gc.DB.Transaction(func(tx *gorm.DB) error {
+		// Replies move up to the parent of the deleted comment so the rest of the thread stays intact
+		result := tx.Model(&models.Comment{}).
+			Where("parent_id = ?", comment.CommentID).
+			UpdateColumn("parent_id", comment.ParentID)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+
+		for _, model := range []interface{}{&models.CommentReaction{}, &models.CommentMention{}, &models.Notification{}} {
+			result = tx.Where("comment_id = ?", comment.CommentID).Delete(model)
+			if result.Error != nil {
+				zap.L().Error(result.Error.Error())
+				return result.Error
+			}
+		}
+
+		result = tx.Delete(&comment)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+
+		return touchGist(tx, gist.ID)
+	})
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	gistEvents.publish(gist.ID, gistEventCommentDeleted, models.DeletedCommentEvent{CommentID: comment.CommentID})
+	ctx.Status(http.StatusNoContent)
+}
+
+// loadGistComment loads the comment in the commentId path parameter which must belong to the visible gist in the
+// gistId path parameter, an error response is written and false is returned otherwise
+func (gc *GistController) loadGistComment(ctx *gin.Context) (models.Gist, models.Comment, bool) {
+	var comment models.Comment
+
+	gist, ok := newGistPolicy(ctx, gc.DB).loadVisibleGist(ctx, gc.DB, ctx.Params.ByName("gistId"))
+	if !ok {
+		return gist, comment, false
+	}
+
+	parsedCommentId, err := uuid.Parse(ctx.Params.ByName("commentId"))
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+		return gist, comment, false
+	}
+
+	result := gc.DB.First(&comment, "comment_id = ? AND gist_id = ?", parsedCommentId, gist.ID)
+	if result.Error != nil {
+		utils.NewErrorResponse(ctx, http.StatusNotFound, "comment does not exist")
+		return gist, comment, false
 	}
 
-	ctx.JSON(http.StatusOK, models.StringArrayWrapper{StringArray: stargazers})
+	return gist, comment, true
 }
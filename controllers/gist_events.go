@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	gistEventCommentCreated = "comment_created"
+	gistEventCommentEdited  = "comment_edited"
+	gistEventCommentDeleted = "comment_deleted"
+	gistEventStarCount      = "star_count"
+)
+
+// Number of recent events kept (across all gists) for clients resuming with Last-Event-ID
+const gistEventHistorySize = 1000
+
+// Events buffered per subscriber, subscribers which fall further behind are disconnected and have to resume
+const gistEventSubscriberBuffer = 32
+
+const gistEventHeartbeatInterval = 30 * time.Second
+
+type gistEvent struct {
+	ID     int64
+	GistID uuid.UUID
+	Type   string
+	Data   []byte
+}
+
+// gistEventBroker is an in-process pub/sub of the events happening on gists, it only reaches the clients connected to
+// this instance of the API
+type gistEventBroker struct {
+	mu          sync.Mutex
+	lastID      int64
+	history     []gistEvent
+	subscribers map[uuid.UUID]map[chan gistEvent]bool
+}
+
+// Event IDs start at the current time so that they keep increasing across restarts
+var gistEvents = &gistEventBroker{
+	lastID:      time.Now().UnixMilli(),
+	subscribers: make(map[uuid.UUID]map[chan gistEvent]bool),
+}
+
+func (b *gistEventBroker) publish(gistId uuid.UUID, eventType string, data interface{}) {
+	encodedData, err := json.Marshal(data)
+	if err != nil {
+		zap.L().Error(err.Error())
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := gistEvent{ID: b.lastID, GistID: gistId, Type: eventType, Data: encodedData}
+
+	b.history = append(b.history, event)
+	if len(b.history) > gistEventHistorySize {
+		b.history = b.history[len(b.history)-gistEventHistorySize:]
+	}
+
+	for subscriber := range b.subscribers[gistId] {
+		select {
+		case subscriber <- event:
+		default:
+			b.removeSubscriber(gistId, subscriber)
+		}
+	}
+}
+
+// subscribe returns a channel receiving the events of the gist along with the recent events newer than lastEventId
+func (b *gistEventBroker) subscribe(gistId uuid.UUID, lastEventId int64) (chan gistEvent, []gistEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	missedEvents := make([]gistEvent, 0)
+	if lastEventId > 0 {
+		for _, event := range b.history {
+			if event.GistID == gistId && event.ID > lastEventId {
+				missedEvents = append(missedEvents, event)
+			}
+		}
+	}
+
+	subscriber := make(chan gistEvent, gistEventSubscriberBuffer)
+	if b.subscribers[gistId] == nil {
+		b.subscribers[gistId] = make(map[chan gistEvent]bool)
+	}
+	b.subscribers[gistId][subscriber] = true
+
+	return subscriber, missedEvents
+}
+
+func (b *gistEventBroker) unsubscribe(gistId uuid.UUID, subscriber chan gistEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.removeSubscriber(gistId, subscriber)
+}
+
+// removeSubscriber closes the channel of the subscriber, b.mu must be held
+func (b *gistEventBroker) removeSubscriber(gistId uuid.UUID, subscriber chan gistEvent) {
+	if !b.subscribers[gistId][subscriber] {
+		return
+	}
+
+	delete(b.subscribers[gistId], subscriber)
+	if len(b.subscribers[gistId]) == 0 {
+		delete(b.subscribers, gistId)
+	}
+	close(subscriber)
+}
+
+func writeGistEvent(ctx *gin.Context, event gistEvent) {
+	fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	ctx.Writer.Flush()
+}
+
+//	@Summary		Stream the events of a gist as Server-Sent Events
+//	@Description	Pushes comment_created, comment_edited (models.Comment), comment_deleted (models.DeletedCommentEvent) and
+//	@Description	star_count (models.StarCountEvent) events. Reconnecting clients receive the events they missed when
+//	@Description	sending the Last-Event-ID header. The stream ends once the gist is deleted or the current user is not
+//	@Description	allowed to see it anymore.
+//	@Tags			Gist Operations
+//	@Produce		text/event-stream
+//	@Param			gistId			path	string	true	"The ID of the gist"
+//	@Param			Last-Event-ID	header	string	false	"The ID of the last event received"
+//	@Success		200
+//	@Failure		400	{object}	models.ErrorResponseWrapper
+//	@Failure		404	{object}	models.ErrorResponseWrapper
+//	@Router			/gists/{gistId}/events [get]
+func (gc *GistController) StreamGistEvents(ctx *gin.Context) {
+	gistId := ctx.Params.ByName("gistId")
+
+	gist, ok := newGistPolicy(ctx, gc.DB).loadVisibleGist(ctx, gc.DB, gistId)
+	if !ok {
+		return
+	}
+
+	var lastEventId int64
+	if header := ctx.GetHeader("Last-Event-ID"); header != "" {
+		lastEventId, _ = strconv.ParseInt(header, 10, 64)
+	}
+
+	subscriber, missedEvents := gistEvents.subscribe(gist.ID, lastEventId)
+	defer gistEvents.unsubscribe(gist.ID, subscriber)
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	for _, event := range missedEvents {
+		writeGistEvent(ctx, event)
+	}
+
+	heartbeat := time.NewTicker(gistEventHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, open := <-subscriber:
+			if !open {
+				// Too slow to keep up, the client reconnects and resumes from the last event it received
+				return
+			}
+			if !gc.canStillView(ctx, gist.ID) {
+				return
+			}
+			writeGistEvent(ctx, event)
+		case <-heartbeat.C:
+			if !gc.canStillView(ctx, gist.ID) {
+				return
+			}
+			fmt.Fprint(ctx.Writer, ": ping\n\n")
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+// canStillView reports whether the current user can still see the gist of an open stream, it might have been made
+// private, unshared or moved to the trash since the stream started. Reconnecting clients get a 404 response then.
+func (gc *GistController) canStillView(ctx *gin.Context, gistId uuid.UUID) bool {
+	var gist models.Gist
+	result := gc.DB.Select("id", "username", "private").First(&gist, "id = ?", gistId)
+	if result.Error != nil {
+		if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			zap.L().Error(result.Error.Error())
+		}
+		return false
+	}
+
+	return newGistPolicy(ctx, gc.DB).canView(gist)
+}
+
+func publishStarCount(gist models.Gist) {
+	gistEvents.publish(gist.ID, gistEventStarCount, models.StarCountEvent{GistID: gist.ID, StarCount: gist.StarCount})
+}
@@ -0,0 +1,779 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// orderedFiles is used while preloading gist files so that they are returned in the order the owner created them
+func orderedFiles(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
+
+func newGistFile(filename, content string, position int) models.GistContent {
+	return models.GistContent{
+		Filename: filename,
+		Language: utils.DetectLanguage(filename),
+		Size:     len(content),
+		Position: position,
+		Content:  content,
+	}
+}
+
+func toGistWithoutComments(gist models.Gist) models.GistWithoutComments {
+	return models.GistWithoutComments{
+		Username:   gist.Username,
+		StarCount:  gist.StarCount,
+		ForkCount:  gist.ForkCount,
+		ForkedFrom: gist.ForkedFrom,
+		ID:         gist.ID,
+		Private:    gist.Private,
+		Files:      gist.Files,
+		Name:       gist.Name,
+		Title:      gist.Title,
+		CreatedAt:  gist.CreatedAt,
+		UpdatedAt:  gist.UpdatedAt,
+		Reactions:  emptyReactionCounts(),
+		Tags:       make([]string, 0),
+	}
+}
+
+// withGistDetails fills the reaction counts and the tags of the gists in place
+func withGistDetails(db *gorm.DB, gists []models.GistWithoutComments) {
+	withGistReactions(db, gists)
+	withGistTags(db, gists)
+}
+
+func gistWithDetails(db *gorm.DB, gist models.Gist) models.GistWithoutComments {
+	gists := []models.GistWithoutComments{toGistWithoutComments(gist)}
+	withGistDetails(db, gists)
+	return gists[0]
+}
+
+// gistVersion identifies the version of the content of a gist, every update of the gist changes UpdatedAt. Updates
+// are only rejected by If-Match preconditions when it changed.
+func gistVersion(id uuid.UUID, updatedAt time.Time) string {
+	return id.String() + ":" + strconv.FormatInt(updatedAt.UnixMicro(), 10)
+}
+
+// touchGist sets the last activity of the gist after a change which is part of the gist or of its comments as returned
+// by the API but leaves the content version untouched, like a reaction or a comment
+func touchGist(db *gorm.DB, gistId uuid.UUID) error {
+	result := db.Model(&models.Gist{}).Where("id = ?", gistId).UpdateColumn("last_activity_at", time.Now())
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+	}
+	return result.Error
+}
+
+// lastGistActivity is the Last-Modified date of a page of gists
+func lastGistActivity(gists []models.Gist) time.Time {
+	var lastActivity time.Time
+	for _, gist := range gists {
+		if gist.LastActivityAt.After(lastActivity) {
+			lastActivity = gist.LastActivityAt
+		}
+	}
+	return lastActivity
+}
+
+// gistETag identifies the version of a gist as returned by the API, the star count, the fork count and the reactions
+// change independently of the content version
+func gistETag(gist models.GistWithoutComments) string {
+	return utils.NewVersionedETag(
+		gistVersion(gist.ID, gist.UpdatedAt),
+		strconv.Itoa(gist.StarCount),
+		strconv.Itoa(gist.ForkCount),
+		reactionsVersion(gist.Reactions),
+	)
+}
+
+// gistsETag identifies the version of a list of gists, adding, removing or reordering gists changes it as well
+func gistsETag(gists []models.GistWithoutComments) string {
+	etags := make([]string, 0, len(gists))
+	for _, gist := range gists {
+		etags = append(etags, gistETag(gist))
+	}
+	return utils.NewETag(etags...)
+}
+
+// commentsETag identifies the version of a list of comments. Re-anchoring line comments and re-parenting replies does
+// not change UpdatedAt, the affected fields are part of the version.
+func commentsETag(comments []models.Comment) string {
+	parts := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		parentId := ""
+		if comment.ParentID != nil {
+			parentId = comment.ParentID.String()
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d:%s:%d:%d:%t:%s",
+			comment.CommentID,
+			comment.UpdatedAt.UnixMicro(),
+			parentId,
+			comment.StartLine,
+			comment.EndLine,
+			comment.Outdated,
+			reactionsVersion(comment.Reactions),
+		))
+	}
+	return utils.NewETag(parts...)
+}
+
+func reactionsVersion(reactions map[string]int) string {
+	counts := make([]string, 0, len(reactionContents))
+	for _, content := range reactionContents {
+		counts = append(counts, strconv.Itoa(reactions[content]))
+	}
+	return strings.Join(counts, ",")
+}
+
+// isPublicResponse reports whether the response can be stored by shared caches, only responses to anonymous requests
+// can since anonymous users only see public gists
+func isPublicResponse(ctx *gin.Context) bool {
+	_, authenticated := ctx.Get("currentUser")
+	return !authenticated
+}
+
+// errGistModified aborts an update based on an outdated version of the gist
+var errGistModified = errors.New("gist was modified")
+
+// errGistBeingEdited aborts an update of a gist edited in a live session
+var errGistBeingEdited = errors.New("gist is being edited in a live session")
+
+// respondGistModified rejects a conditional update with the current version of the gist so that the client can merge
+// its changes
+func respondGistModified(ctx *gin.Context, db *gorm.DB, gist models.Gist) {
+	currentGist := gistWithDetails(db, gist)
+	ctx.Header("ETag", gistETag(currentGist))
+	ctx.JSON(http.StatusPreconditionFailed, models.GistWithoutCommentsWrapper{
+		Gist: currentGist,
+	})
+}
+
+func findGistFile(files []models.GistContent, filename string) int {
+	for i, file := range files {
+		if file.Filename == filename {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyFileChanges applies the requested create/update/delete operations on the files of a gist. It returns the
+// resulting files (positions re-numbered) and the already persisted files which have to be deleted.
+func applyFileChanges(files []models.GistContent, changes []models.UpdateGistFileRequest) ([]models.GistContent, []models.GistContent, error) {
+	updatedFiles := make([]models.GistContent, len(files))
+	copy(updatedFiles, files)
+	deletedFiles := make([]models.GistContent, 0)
+
+	for _, change := range changes {
+		index := findGistFile(updatedFiles, change.Filename)
+
+		switch {
+		case change.Delete:
+			if index == -1 {
+				return nil, nil, fmt.Errorf("file with name: '%s' does not exist", change.Filename)
+			}
+			if updatedFiles[index].ID != uuid.Nil {
+				deletedFiles = append(deletedFiles, updatedFiles[index])
+			}
+			updatedFiles = append(updatedFiles[:index], updatedFiles[index+1:]...)
+
+		case index == -1:
+			if change.Content == "" {
+				return nil, nil, fmt.Errorf("content is required for new file: '%s'", change.Filename)
+			}
+			filename := change.Filename
+			if change.NewFilename != "" {
+				filename = change.NewFilename
+				if findGistFile(updatedFiles, filename) != -1 {
+					return nil, nil, fmt.Errorf("file with name: '%s' already exists", filename)
+				}
+			}
+			updatedFiles = append(updatedFiles, newGistFile(filename, change.Content, len(updatedFiles)))
+
+		default:
+			file := &updatedFiles[index]
+			if change.NewFilename != "" && change.NewFilename != file.Filename {
+				if findGistFile(updatedFiles, change.NewFilename) != -1 {
+					return nil, nil, fmt.Errorf("file with name: '%s' already exists", change.NewFilename)
+				}
+				file.Filename = change.NewFilename
+				file.Language = utils.DetectLanguage(change.NewFilename)
+			}
+			if change.Content != "" {
+				file.Content = change.Content
+				file.Size = len(change.Content)
+			}
+		}
+	}
+
+	if len(updatedFiles) == 0 {
+		return nil, nil, fmt.Errorf("a gist must have at least one file")
+	}
+
+	for i := range updatedFiles {
+		updatedFiles[i].Position = i
+	}
+
+	return updatedFiles, deletedFiles, nil
+}
+
+// recordGistRevision stores a snapshot of the current state of the gist as its next revision. The gist row must be
+// locked for existing gists, concurrent saves would otherwise get the same revision number.
+func recordGistRevision(tx *gorm.DB, gist models.Gist, author string, createdAt time.Time) (models.GistRevision, error) {
+	var latestRevision int
+	result := tx.Model(&models.GistRevision{}).
+		Select("COALESCE(MAX(revision), 0)").
+		Where("gist_id = ?", gist.ID).
+		Scan(&latestRevision)
+	if result.Error != nil {
+		return models.GistRevision{}, result.Error
+	}
+
+	revision := models.GistRevision{
+		GistID:    gist.ID,
+		Revision:  latestRevision + 1,
+		Author:    author,
+		Name:      gist.Name,
+		Title:     gist.Title,
+		Files:     gistRevisionFiles(gist),
+		CreatedAt: createdAt,
+	}
+
+	result = tx.Create(&revision)
+	return revision, result.Error
+}
+
+// gistRevisionFiles copies the files of a gist for a revision
+func gistRevisionFiles(gist models.Gist) []models.GistRevisionFile {
+	files := make([]models.GistRevisionFile, 0, len(gist.Files))
+	for _, file := range gist.Files {
+		files = append(files, models.GistRevisionFile{
+			Filename: file.Filename,
+			Language: file.Language,
+			Size:     file.Size,
+			Position: file.Position,
+			Content:  file.Content,
+		})
+	}
+	return files
+}
+
+// compareGistRevisions diffs the title, name and files of two revisions, files are matched by their filename
+func compareGistRevisions(from, to models.GistRevision) models.GistComparison {
+	comparison := models.GistComparison{
+		GistID:       to.GistID,
+		FromRevision: from.Revision,
+		ToRevision:   to.Revision,
+		Title:        utils.DiffHunks(from.Title, to.Title, utils.DefaultDiffContextLines),
+		Name:         utils.DiffHunks(from.Name, to.Name, utils.DefaultDiffContextLines),
+		Files:        make([]models.FileDiff, 0),
+	}
+
+	var unified strings.Builder
+	unified.WriteString(utils.FormatUnifiedDiff("a/title", "b/title", comparison.Title))
+	unified.WriteString(utils.FormatUnifiedDiff("a/name", "b/name", comparison.Name))
+
+	fromFiles := make(map[string]models.GistRevisionFile)
+	for _, file := range from.Files {
+		fromFiles[file.Filename] = file
+	}
+	toFiles := make(map[string]bool)
+
+	for _, file := range to.Files {
+		toFiles[file.Filename] = true
+
+		fileDiff := models.FileDiff{NewFilename: file.Filename, Status: "modified"}
+		oldName := "a/" + file.Filename
+		oldFile, ok := fromFiles[file.Filename]
+		if ok {
+			fileDiff.OldFilename = file.Filename
+		} else {
+			fileDiff.Status = "added"
+			oldName = "/dev/null"
+		}
+
+		fileDiff.Hunks = utils.DiffHunks(oldFile.Content, file.Content, utils.DefaultDiffContextLines)
+		if len(fileDiff.Hunks) == 0 {
+			continue
+		}
+		comparison.Files = append(comparison.Files, fileDiff)
+		unified.WriteString(utils.FormatUnifiedDiff(oldName, "b/"+file.Filename, fileDiff.Hunks))
+	}
+
+	for _, file := range from.Files {
+		if toFiles[file.Filename] {
+			continue
+		}
+
+		fileDiff := models.FileDiff{
+			OldFilename: file.Filename,
+			Status:      "deleted",
+			Hunks:       utils.DiffHunks(file.Content, "", utils.DefaultDiffContextLines),
+		}
+		comparison.Files = append(comparison.Files, fileDiff)
+		unified.WriteString(utils.FormatUnifiedDiff("a/"+file.Filename, "/dev/null", fileDiff.Hunks))
+	}
+
+	comparison.Unified = unified.String()
+	return comparison
+}
+
+// forkGistName returns a name for the fork which does not collide with any existing gist of the user
+func forkGistName(name string, userGists []models.Gist) string {
+	taken := make(map[string]bool)
+	for _, gist := range userGists {
+		taken[gist.Name] = true
+	}
+
+	if !taken[name] {
+		return name
+	}
+
+	candidate := name + "-fork"
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-fork-%d", name, i)
+	}
+	return candidate
+}
+
+// saveGistUpdate saves the new state of a gist, records it as a revision and moves the review comments along with the
+// changed lines. deletedFiles are the files of previousGist which are no longer part of gist. It must be run inside a
+// transaction.
+func saveGistUpdate(tx *gorm.DB, previousGist models.Gist, gist *models.Gist, deletedFiles []models.GistContent, author string) (models.GistRevision, error) {
+	// Gists created before revisions were introduced get their current state recorded as the first revision
+	var revisionCount int64
+	result := tx.Model(&models.GistRevision{}).Where("gist_id = ?", gist.ID).Count(&revisionCount)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return models.GistRevision{}, result.Error
+	}
+	if revisionCount == 0 {
+		_, err := recordGistRevision(tx, previousGist, previousGist.Username, previousGist.UpdatedAt)
+		if err != nil {
+			zap.L().Error(err.Error())
+			return models.GistRevision{}, err
+		}
+	}
+
+	if len(deletedFiles) != 0 {
+		result = tx.Delete(&deletedFiles)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return models.GistRevision{}, result.Error
+		}
+	}
+
+	result = tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(gist)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return models.GistRevision{}, result.Error
+	}
+
+	revision, err := recordGistRevision(tx, *gist, author, gist.UpdatedAt)
+	if err != nil {
+		zap.L().Error(err.Error())
+		return models.GistRevision{}, err
+	}
+
+	err = reanchorLineComments(tx, gist.ID, previousGist.Files, gist.Files, revision.Revision)
+	if err != nil {
+		zap.L().Error(err.Error())
+		return models.GistRevision{}, err
+	}
+
+	err = recordActivity(tx, author, activityGistUpdated, gist.ID, nil, gist.UpdatedAt)
+	if err != nil {
+		return models.GistRevision{}, err
+	}
+	return revision, nil
+}
+
+// amendGistUpdate saves the new state of a gist like saveGistUpdate, but replaces revision, recorded by a previous
+// save, and moves its activity entry instead of recording new ones. The files of the gist must not have been added or
+// deleted since. It must be run inside a transaction.
+func amendGistUpdate(tx *gorm.DB, previousGist models.Gist, gist *models.Gist, revision models.GistRevision, author string) (models.GistRevision, error) {
+	result := tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(gist)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return models.GistRevision{}, result.Error
+	}
+
+	result = tx.Where("revision_id = ?", revision.ID).Delete(&models.GistRevisionFile{})
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return models.GistRevision{}, result.Error
+	}
+	files := gistRevisionFiles(*gist)
+	for i := range files {
+		files[i].RevisionID = revision.ID
+	}
+	if len(files) != 0 {
+		result = tx.Create(&files)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return models.GistRevision{}, result.Error
+		}
+	}
+
+	result = tx.Model(&models.ActivityEvent{}).
+		Where("gist_id = ? AND type = ? AND actor = ? AND created_at = ?", gist.ID, activityGistUpdated, revision.Author, revision.CreatedAt).
+		UpdateColumns(map[string]interface{}{"actor": author, "created_at": gist.UpdatedAt})
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return models.GistRevision{}, result.Error
+	}
+
+	amendedRevision := revision
+	amendedRevision.Author = author
+	amendedRevision.Name = gist.Name
+	amendedRevision.Title = gist.Title
+	amendedRevision.Files = files
+	amendedRevision.CreatedAt = gist.UpdatedAt
+	result = tx.Model(&revision).UpdateColumns(map[string]interface{}{
+		"author":     amendedRevision.Author,
+		"name":       amendedRevision.Name,
+		"title":      amendedRevision.Title,
+		"created_at": amendedRevision.CreatedAt,
+	})
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return models.GistRevision{}, result.Error
+	}
+
+	err := reanchorLineComments(tx, gist.ID, previousGist.Files, gist.Files, revision.Revision)
+	if err != nil {
+		zap.L().Error(err.Error())
+		return models.GistRevision{}, err
+	}
+	return amendedRevision, nil
+}
+
+// deleteGistCascade permanently removes a gist (trashed or not) and every row referencing it, the star count of every stargazer and
+// the fork count of the parent gist are decremented. It must be run inside a transaction.
+func deleteGistCascade(tx *gorm.DB, gist models.Gist) error {
+	var stargazers []string
+	result := tx.Model(&models.Star{}).Where("gist_id = ?", gist.ID).Pluck("username", &stargazers)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return result.Error
+	}
+
+	if len(stargazers) != 0 {
+		result = tx.Model(&models.UserMetadata{}).
+			Where("username IN ?", stargazers).
+			Update("starred_gists_count", gorm.Expr("GREATEST(starred_gists_count - 1, 0)"))
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+	}
+
+	if gist.ForkedFrom != nil {
+		result = tx.Model(&models.Gist{}).
+			Where("id = ?", *gist.ForkedFrom).
+			UpdateColumns(map[string]interface{}{
+				"fork_count":       gorm.Expr("GREATEST(fork_count - 1, 0)"),
+				"last_activity_at": time.Now(),
+			})
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+	}
+
+	revisionIds := tx.Model(&models.GistRevision{}).Select("id").Where("gist_id = ?", gist.ID)
+	commentIds := tx.Model(&models.Comment{}).Select("comment_id").Where("gist_id = ?", gist.ID)
+	deletions := []struct {
+		model interface{}
+		query string
+		arg   interface{}
+	}{
+		{&models.Star{}, "gist_id = ?", gist.ID},
+		{&models.GistReaction{}, "gist_id = ?", gist.ID},
+		{&models.CommentReaction{}, "comment_id IN (?)", commentIds},
+		{&models.CommentMention{}, "comment_id IN (?)", commentIds},
+		{&models.Notification{}, "gist_id = ?", gist.ID},
+		{&models.ActivityEvent{}, "? IN (gist_id, fork_id)", gist.ID},
+		{&models.Comment{}, "gist_id = ?", gist.ID},
+		{&models.GistShare{}, "gist_id = ?", gist.ID},
+		{&models.GistCollaborator{}, "gist_id = ?", gist.ID},
+		{&models.GistTag{}, "gist_id = ?", gist.ID},
+		{&models.GistContent{}, "gist_id = ?", gist.ID},
+		{&models.GistRevisionFile{}, "revision_id IN (?)", revisionIds},
+		{&models.GistRevision{}, "gist_id = ?", gist.ID},
+		{&models.Gist{}, "id = ?", gist.ID},
+	}
+	for _, deletion := range deletions {
+		result = tx.Unscoped().Where(deletion.query, deletion.arg).Delete(deletion.model)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+// threadComments orders the comments of a gist as a depth first traversal of the reply tree and sets their depth.
+// Comments must be sorted by creation time, replies are therefore also ordered by creation time. Replies whose
+// parent does not exist anymore are treated as top level comments.
+func threadComments(comments []models.Comment) []models.Comment {
+	exists := make(map[uuid.UUID]bool)
+	for _, comment := range comments {
+		exists[comment.CommentID] = true
+	}
+
+	roots := make([]models.Comment, 0)
+	replies := make(map[uuid.UUID][]models.Comment)
+	for _, comment := range comments {
+		if comment.ParentID == nil || !exists[*comment.ParentID] {
+			roots = append(roots, comment)
+		} else {
+			replies[*comment.ParentID] = append(replies[*comment.ParentID], comment)
+		}
+	}
+
+	threaded := make([]models.Comment, 0, len(comments))
+	var visit func(comment models.Comment, depth int)
+	visit = func(comment models.Comment, depth int) {
+		comment.Depth = depth
+		threaded = append(threaded, comment)
+		for _, reply := range replies[comment.CommentID] {
+			visit(reply, depth+1)
+		}
+	}
+	for _, root := range roots {
+		visit(root, 0)
+	}
+
+	return threaded
+}
+
+// loadCommentThreads returns the top level comments, in the same order, followed by all their replies sorted by
+// creation time so that the result can be passed to threadComments
+func loadCommentThreads(db *gorm.DB, topLevelComments []models.Comment) ([]models.Comment, error) {
+	if len(topLevelComments) == 0 {
+		return topLevelComments, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(topLevelComments))
+	for _, comment := range topLevelComments {
+		ids = append(ids, comment.CommentID)
+	}
+
+	var replies []models.Comment
+	result := db.Raw(`WITH RECURSIVE replies AS (
+		SELECT * FROM comments WHERE parent_id IN ?
+		UNION ALL
+		SELECT comments.* FROM comments JOIN replies ON comments.parent_id = replies.comment_id
+	) SELECT * FROM replies ORDER BY created_at ASC`, ids).Scan(&replies)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return append(topLevelComments, replies...), nil
+}
+
+// commentDepth returns the number of ancestors of a comment
+func commentDepth(db *gorm.DB, comment models.Comment) (int, error) {
+	depth := 0
+	for comment.ParentID != nil {
+		var parent models.Comment
+		result := db.First(&parent, "comment_id = ?", *comment.ParentID)
+		if result.Error == gorm.ErrRecordNotFound {
+			break
+		} else if result.Error != nil {
+			return 0, result.Error
+		}
+		comment = parent
+		depth++
+	}
+	return depth, nil
+}
+
+// anchorLineComment validates the requested line range against the files of the requested revision (the latest one
+// by default) and anchors the comment to it. Comments on an older revision are outdated right away.
+func anchorLineComment(db *gorm.DB, comment *models.Comment, gist models.Gist, payload *models.CommentOnGistRequest) error {
+	var latestRevision int
+	result := db.Model(&models.GistRevision{}).
+		Select("COALESCE(MAX(revision), 0)").
+		Where("gist_id = ?", gist.ID).
+		Scan(&latestRevision)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	revision := payload.Revision
+	if revision == 0 {
+		revision = latestRevision
+	}
+
+	var content string
+	if revision == latestRevision {
+		index := findGistFile(gist.Files, payload.Filename)
+		if index == -1 {
+			return fmt.Errorf("file with name: '%s' does not exist", payload.Filename)
+		}
+		content = gist.Files[index].Content
+	} else {
+		var revisionFile models.GistRevisionFile
+		result = db.
+			Joins("JOIN gist_revisions ON gist_revisions.id = gist_revision_files.revision_id").
+			Where("gist_revisions.gist_id = ? AND gist_revisions.revision = ?", gist.ID, revision).
+			First(&revisionFile, "gist_revision_files.filename = ?", payload.Filename)
+		if result.Error != nil {
+			return fmt.Errorf("file with name: '%s' does not exist in revision %d", payload.Filename, revision)
+		}
+		content = revisionFile.Content
+	}
+
+	endLine := payload.EndLine
+	if endLine == 0 {
+		endLine = payload.StartLine
+	}
+	if payload.StartLine < 1 || endLine < payload.StartLine || endLine > len(utils.SplitLines(content)) {
+		return fmt.Errorf("invalid line range %d-%d for file: '%s'", payload.StartLine, endLine, payload.Filename)
+	}
+
+	comment.Filename = payload.Filename
+	comment.StartLine = payload.StartLine
+	comment.EndLine = endLine
+	comment.Revision = revision
+	comment.Outdated = revision != latestRevision
+	return nil
+}
+
+// reanchorLineComments moves the review comments of a gist along with their lines after the files changed from
+// previousFiles to files. Files are matched by ID so renamed files keep their comments. Comments whose lines were
+// changed, or whose file was deleted or has more than utils.MaxDiffEdits changes, are marked as outdated and keep
+// their original anchor.
+func reanchorLineComments(tx *gorm.DB, gistId uuid.UUID, previousFiles, files []models.GistContent, revision int) error {
+	var comments []models.Comment
+	result := tx.Find(&comments, "gist_id = ? AND filename <> '' AND outdated = ?", gistId, false)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	lineMaps := make(map[string]map[int]int)
+	for _, comment := range comments {
+		previousIndex := findGistFile(previousFiles, comment.Filename)
+
+		var file *models.GistContent
+		if previousIndex != -1 {
+			for i := range files {
+				if files[i].ID == previousFiles[previousIndex].ID {
+					file = &files[i]
+					break
+				}
+			}
+		}
+
+		// Files with too many changes have no line map, their comments are outdated
+		var lineMap map[int]int
+		if file != nil {
+			var mapped bool
+			lineMap, mapped = lineMaps[comment.Filename]
+			if !mapped {
+				lineMap, _ = utils.MapLines(previousFiles[previousIndex].Content, file.Content)
+				lineMaps[comment.Filename] = lineMap
+			}
+		}
+
+		outdated := true
+		startLine, endLine := comment.StartLine, comment.EndLine
+		if lineMap != nil {
+			// Every line of the range has to survive unchanged and stay contiguous
+			outdated = false
+			startLine = lineMap[comment.StartLine]
+			for line := comment.StartLine; line <= comment.EndLine; line++ {
+				newLine, ok := lineMap[line]
+				if !ok || newLine != startLine+line-comment.StartLine {
+					outdated = true
+					break
+				}
+			}
+			endLine = startLine + comment.EndLine - comment.StartLine
+		}
+
+		updates := map[string]interface{}{"outdated": true}
+		if !outdated {
+			updates = map[string]interface{}{
+				"filename":   file.Filename,
+				"start_line": startLine,
+				"end_line":   endLine,
+				"revision":   revision,
+			}
+		}
+
+		result = tx.Model(&comment).UpdateColumns(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+// groupLineComments groups the review comments of a gist by the lines they are anchored to, every group contains the
+// anchored comments followed by their replies. Groups are ordered by file and line, outdated groups come last.
+func groupLineComments(comments []models.Comment) []models.LineCommentGroup {
+	groups := make([]models.LineCommentGroup, 0)
+	groupIndex := make(map[string]int)
+
+	current := -1
+	for _, comment := range threadComments(comments) {
+		if comment.Depth == 0 {
+			current = -1
+			if comment.Filename == "" {
+				continue
+			}
+
+			key := fmt.Sprintf("%s:%d:%d:%d:%t",
+				comment.Filename, comment.StartLine, comment.EndLine, comment.Revision, comment.Outdated)
+			index, ok := groupIndex[key]
+			if !ok {
+				index = len(groups)
+				groupIndex[key] = index
+				groups = append(groups, models.LineCommentGroup{
+					Filename:  comment.Filename,
+					StartLine: comment.StartLine,
+					EndLine:   comment.EndLine,
+					Revision:  comment.Revision,
+					Outdated:  comment.Outdated,
+					Comments:  make([]models.Comment, 0),
+				})
+			}
+			current = index
+		}
+
+		if current != -1 {
+			groups[current].Comments = append(groups[current].Comments, comment)
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Outdated != groups[j].Outdated {
+			return !groups[i].Outdated
+		}
+		if groups[i].Filename != groups[j].Filename {
+			return groups[i].Filename < groups[j].Filename
+		}
+		return groups[i].StartLine < groups[j].StartLine
+	})
+
+	return groups
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// gistPolicy decides which gists the user making the request is allowed to see. Public gists are visible to everyone,
+// private gists only to their owner, the users they are shared with and their collaborators.
+type gistPolicy struct {
+	db     *gorm.DB
+	viewer *models.User
+
+	// IDs of the gists shared with the viewer and of the gists the viewer collaborates on, loaded on first use
+	shared        map[uuid.UUID]bool
+	collaborating map[uuid.UUID]bool
+}
+
+// newGistPolicy builds the policy for the current user, the user is optional so that the policy can be used on
+// routes which do not require authentication
+func newGistPolicy(ctx *gin.Context, db *gorm.DB) *gistPolicy {
+	policy := &gistPolicy{db: db}
+	if value, exists := ctx.Get("currentUser"); exists {
+		currentUser := value.(models.User)
+		policy.viewer = &currentUser
+	}
+	return policy
+}
+
+func (p *gistPolicy) isOwner(gist models.Gist) bool {
+	return p.viewer != nil && p.viewer.Username == gist.Username
+}
+
+func (p *gistPolicy) canView(gist models.Gist) bool {
+	if !gist.Private || p.isOwner(gist) {
+		return true
+	}
+	if p.viewer == nil {
+		return false
+	}
+
+	if p.shared == nil {
+		var shares []models.GistShare
+		result := p.db.Find(&shares, "username = ?", p.viewer.Username)
+		if result.Error != nil {
+			// Fail closed, the gist is treated as not visible
+			zap.L().Error(result.Error.Error())
+			return false
+		}
+
+		p.shared = make(map[uuid.UUID]bool)
+		for _, share := range shares {
+			p.shared[share.GistID] = true
+		}
+	}
+
+	return p.shared[gist.ID] || p.canEdit(gist)
+}
+
+// canEdit reports whether the viewer can edit the content of the gist, only the owner and the invited collaborators can
+func (p *gistPolicy) canEdit(gist models.Gist) bool {
+	if p.isOwner(gist) {
+		return true
+	}
+	if p.viewer == nil {
+		return false
+	}
+
+	if p.collaborating == nil {
+		var collaborations []models.GistCollaborator
+		result := p.db.Find(&collaborations, "username = ?", p.viewer.Username)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return false
+		}
+
+		p.collaborating = make(map[uuid.UUID]bool)
+		for _, collaboration := range collaborations {
+			p.collaborating[collaboration.GistID] = true
+		}
+	}
+
+	return p.collaborating[gist.ID]
+}
+
+// visibleScope restricts a query on gists to the gists the viewer is allowed to see, it is the query counterpart of
+// canView for lists which are filtered while being read
+func (p *gistPolicy) visibleScope(query *gorm.DB) *gorm.DB {
+	if p.viewer == nil {
+		return query.Where("gists.private = ?", false)
+	}
+
+	sharedGists := p.db.Model(&models.GistShare{}).Select("gist_id").Where("username = ?", p.viewer.Username)
+	collaboratingGists := p.db.Model(&models.GistCollaborator{}).Select("gist_id").Where("username = ?", p.viewer.Username)
+	return query.Where(
+		"gists.private = ? OR gists.username = ? OR gists.id IN (?) OR gists.id IN (?)",
+		false, p.viewer.Username, sharedGists, collaboratingGists,
+	)
+}
+
+// filterVisible returns the gists the viewer is allowed to see, in the same order
+func (p *gistPolicy) filterVisible(gists []models.Gist) []models.Gist {
+	visibleGists := make([]models.Gist, 0, len(gists))
+	for _, gist := range gists {
+		if p.canView(gist) {
+			visibleGists = append(visibleGists, gist)
+		}
+	}
+	return visibleGists
+}
+
+// loadVisibleGist loads the gist with the given ID using query. If the gist does not exist or the viewer is
+// not allowed to see it an error response is written and false is returned. Invisible gists are reported as not
+// existing so that their IDs are not leaked.
+func (p *gistPolicy) loadVisibleGist(ctx *gin.Context, query *gorm.DB, gistId string) (models.Gist, bool) {
+	var gist models.Gist
+
+	parsedGistId, err := uuid.Parse(gistId)
+	if err != nil {
+		zap.L().Error(err.Error())
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid gist id")
+		return gist, false
+	}
+
+	result := query.First(&gist, "id = ?", parsedGistId)
+	if result.Error != nil || !p.canView(gist) {
+		utils.NewErrorResponse(ctx, http.StatusNotFound, "gist does not exist")
+		return gist, false
+	}
+
+	return gist, true
+}
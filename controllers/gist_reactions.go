@@ -0,0 +1,402 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The reactions users can add to gists and comments
+var reactionContents = []string{"+1", "-1", "laugh", "heart", "rocket", "eyes"}
+
+func isValidReaction(content string) bool {
+	for _, reactionContent := range reactionContents {
+		if reactionContent == content {
+			return true
+		}
+	}
+	return false
+}
+
+func emptyReactionCounts() map[string]int {
+	counts := make(map[string]int, len(reactionContents))
+	for _, content := range reactionContents {
+		counts[content] = 0
+	}
+	return counts
+}
+
+type reactionCount struct {
+	ID      uuid.UUID
+	Content string
+	Count   int
+}
+
+// countReactions returns the number of reactions per content for every ID, model is either GistReaction or
+// CommentReaction and idColumn the column referencing the gist or the comment
+func countReactions(db *gorm.DB, model interface{}, idColumn string, ids []uuid.UUID) (map[uuid.UUID]map[string]int, error) {
+	counts := make(map[uuid.UUID]map[string]int, len(ids))
+	for _, id := range ids {
+		counts[id] = emptyReactionCounts()
+	}
+	if len(ids) == 0 {
+		return counts, nil
+	}
+
+	var rows []reactionCount
+	result := db.Model(model).
+		Select(idColumn+" AS id, content, COUNT(*) AS count").
+		Where(idColumn+" IN ?", ids).
+		Group(idColumn + ", content").
+		Scan(&rows)
+	if result.Error != nil {
+		return counts, result.Error
+	}
+
+	for _, row := range rows {
+		counts[row.ID][row.Content] = row.Count
+	}
+	return counts, nil
+}
+
+// withGistReactions fills the reaction counts of the gists in place
+func withGistReactions(db *gorm.DB, gists []models.GistWithoutComments) {
+	ids := make([]uuid.UUID, 0, len(gists))
+	for _, gist := range gists {
+		ids = append(ids, gist.ID)
+	}
+
+	counts, err := countReactions(db, &models.GistReaction{}, "gist_id", ids)
+	if err != nil {
+		zap.L().Error(err.Error())
+	}
+	for i := range gists {
+		gists[i].Reactions = counts[gists[i].ID]
+	}
+}
+
+// withCommentReactions fills the reaction counts of the comments in place
+func withCommentReactions(db *gorm.DB, comments []models.Comment) {
+	ids := make([]uuid.UUID, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.CommentID)
+	}
+
+	counts, err := countReactions(db, &models.CommentReaction{}, "comment_id", ids)
+	if err != nil {
+		zap.L().Error(err.Error())
+	}
+	for i := range comments {
+		comments[i].Reactions = counts[comments[i].CommentID]
+	}
+}
+
+func commentWithReactions(db *gorm.DB, comment models.Comment) models.Comment {
+	comments := []models.Comment{comment}
+	withCommentReactions(db, comments)
+	return comments[0]
+}
+
+func bindReaction(ctx *gin.Context) (string, bool) {
+	var payload *models.ReactionRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+
+	if !isValidReaction(payload.Content) {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest,
+			"invalid reaction, must be one of: "+strings.Join(reactionContents, ", "))
+		return "", false
+	}
+
+	return payload.Content, true
+}
+
+//	@Summary	React to a gist
+//	@Tags		Gist Operations
+//	@Accept		json
+//	@Produce	json
+//	@Param		gistId			path		string					true	"The ID of the gist"
+//	@Param		ReactionInput	body		models.ReactionRequest	true	"The reaction, one of +1, -1, laugh, heart, rocket, eyes"
+//	@Success	200				{object}	models.SuccessResponseWrapper
+//	@Failure	400				{object}	models.ErrorResponseWrapper
+//	@Failure	401				{object}	models.ErrorResponseWrapper
+//	@Failure	403				{object}	models.ErrorResponseWrapper
+//	@Failure	404				{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/reactions [post]
+func (gc *GistController) AddGistReaction(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	gistId := ctx.Params.ByName("gistId")
+
+	content, ok := bindReaction(ctx)
+	if !ok {
+		return
+	}
+
+	gist, ok := newGistPolicy(ctx, gc.DB).loadVisibleGist(ctx, gc.DB, gistId)
+	if !ok {
+		return
+	}
+
+	reaction := models.GistReaction{
+		GistID:    gist.ID,
+		Username:  currentUser.Username,
+		Content:   content,
+		CreatedAt: time.Now(),
+	}
+	err := gc.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		return touchGist(tx, gist.ID)
+	})
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.NewSuccessResponse(ctx, http.StatusOK, "successfully reacted to gist")
+}
+
+//	@Summary	Remove a reaction of the current user from a gist
+//	@Tags		Gist Operations
+//	@Produce	json
+//	@Param		gistId	path		string	true	"The ID of the gist"
+//	@Param		content	path		string	true	"The reaction to remove"
+//	@Success	200		{object}	models.SuccessResponseWrapper
+//	@Failure	400		{object}	models.ErrorResponseWrapper
+//	@Failure	401		{object}	models.ErrorResponseWrapper
+//	@Failure	403		{object}	models.ErrorResponseWrapper
+//	@Failure	404		{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/reactions/{content} [delete]
+func (gc *GistController) RemoveGistReaction(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	gistId := ctx.Params.ByName("gistId")
+
+	gist, ok := newGistPolicy(ctx, gc.DB).loadVisibleGist(ctx, gc.DB, gistId)
+	if !ok {
+		return
+	}
+
+	reactionToDelete := models.GistReaction{
+		GistID:   gist.ID,
+		Username: currentUser.Username,
+		Content:  ctx.Params.ByName("content"),
+	}
+	err := gc.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&reactionToDelete)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		return touchGist(tx, gist.ID)
+	})
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.NewSuccessResponse(ctx, http.StatusOK, "successfully removed reaction from gist")
+}
+
+//	@Summary	Get the users who reacted to a gist
+//	@Tags		Gist Operations
+//	@Produce	json
+//	@Param		gistId	path		string	true	"The ID of the gist"
+//	@Param		content	query		string	false	"Only return the reactions with this content"
+//	@Success	200		{object}	models.ReactionArrayWrapper
+//	@Failure	400		{object}	models.ErrorResponseWrapper
+//	@Failure	404		{object}	models.ErrorResponseWrapper
+//	@Failure	500		{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/reactions [get]
+func (gc *GistController) GetGistReactions(ctx *gin.Context) {
+	gistId := ctx.Params.ByName("gistId")
+
+	gist, ok := newGistPolicy(ctx, gc.DB).loadVisibleGist(ctx, gc.DB, gistId)
+	if !ok {
+		return
+	}
+
+	query := gc.DB.Order("created_at ASC").Where("gist_id = ?", gist.ID)
+	if content := ctx.Query("content"); content != "" {
+		query = query.Where("content = ?", content)
+	}
+
+	var gistReactions []models.GistReaction
+	result := query.Find(&gistReactions)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	reactions := make([]models.Reaction, 0, len(gistReactions))
+	for _, reaction := range gistReactions {
+		reactions = append(reactions, models.Reaction{
+			Username:  reaction.Username,
+			Content:   reaction.Content,
+			CreatedAt: reaction.CreatedAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, models.ReactionArrayWrapper{Reactions: reactions})
+}
+
+//	@Summary	React to a comment on a gist
+//	@Tags		Gist Operations
+//	@Accept		json
+//	@Produce	json
+//	@Param		gistId			path		string					true	"The ID of the gist"
+//	@Param		commentId		path		string					true	"The ID of the comment"
+//	@Param		ReactionInput	body		models.ReactionRequest	true	"The reaction, one of +1, -1, laugh, heart, rocket, eyes"
+//	@Success	200				{object}	models.SuccessResponseWrapper
+//	@Failure	400				{object}	models.ErrorResponseWrapper
+//	@Failure	401				{object}	models.ErrorResponseWrapper
+//	@Failure	403				{object}	models.ErrorResponseWrapper
+//	@Failure	404				{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/comments/{commentId}/reactions [post]
+func (gc *GistController) AddCommentReaction(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	content, ok := bindReaction(ctx)
+	if !ok {
+		return
+	}
+
+	gist, comment, ok := gc.loadGistComment(ctx)
+	if !ok {
+		return
+	}
+
+	reaction := models.CommentReaction{
+		CommentID: comment.CommentID,
+		Username:  currentUser.Username,
+		Content:   content,
+		CreatedAt: time.Now(),
+	}
+	err := gc.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		return touchGist(tx, gist.ID)
+	})
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.NewSuccessResponse(ctx, http.StatusOK, "successfully reacted to comment")
+}
+
+//	@Summary	Remove a reaction of the current user from a comment on a gist
+//	@Tags		Gist Operations
+//	@Produce	json
+//	@Param		gistId		path		string	true	"The ID of the gist"
+//	@Param		commentId	path		string	true	"The ID of the comment"
+//	@Param		content		path		string	true	"The reaction to remove"
+//	@Success	200			{object}	models.SuccessResponseWrapper
+//	@Failure	400			{object}	models.ErrorResponseWrapper
+//	@Failure	401			{object}	models.ErrorResponseWrapper
+//	@Failure	403			{object}	models.ErrorResponseWrapper
+//	@Failure	404			{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/comments/{commentId}/reactions/{content} [delete]
+func (gc *GistController) RemoveCommentReaction(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	gist, comment, ok := gc.loadGistComment(ctx)
+	if !ok {
+		return
+	}
+
+	reactionToDelete := models.CommentReaction{
+		CommentID: comment.CommentID,
+		Username:  currentUser.Username,
+		Content:   ctx.Params.ByName("content"),
+	}
+	err := gc.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&reactionToDelete)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		return touchGist(tx, gist.ID)
+	})
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.NewSuccessResponse(ctx, http.StatusOK, "successfully removed reaction from comment")
+}
+
+//	@Summary	Get the users who reacted to a comment on a gist
+//	@Tags		Gist Operations
+//	@Produce	json
+//	@Param		gistId		path		string	true	"The ID of the gist"
+//	@Param		commentId	path		string	true	"The ID of the comment"
+//	@Param		content		query		string	false	"Only return the reactions with this content"
+//	@Success	200			{object}	models.ReactionArrayWrapper
+//	@Failure	400			{object}	models.ErrorResponseWrapper
+//	@Failure	404			{object}	models.ErrorResponseWrapper
+//	@Failure	500			{object}	models.ErrorResponseWrapper
+//	@Router		/gists/{gistId}/comments/{commentId}/reactions [get]
+func (gc *GistController) GetCommentReactions(ctx *gin.Context) {
+	_, comment, ok := gc.loadGistComment(ctx)
+	if !ok {
+		return
+	}
+
+	query := gc.DB.Order("created_at ASC").Where("comment_id = ?", comment.CommentID)
+	if content := ctx.Query("content"); content != "" {
+		query = query.Where("content = ?", content)
+	}
+
+	var commentReactions []models.CommentReaction
+	result := query.Find(&commentReactions)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	reactions := make([]models.Reaction, 0, len(commentReactions))
+	for _, reaction := range commentReactions {
+		reactions = append(reactions, models.Reaction{
+			Username:  reaction.Username,
+			Content:   reaction.Content,
+			CreatedAt: reaction.CreatedAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, models.ReactionArrayWrapper{Reactions: reactions})
+}
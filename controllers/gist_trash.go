@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/initializers"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+const trashPurgeInterval = time.Hour
+
+func trashRetention(config initializers.Config) time.Duration {
+	if config.GistTrashRetention <= 0 {
+		return defaultTrashRetention
+	}
+	return config.GistTrashRetention
+}
+
+//	@Summary	Get the gists of the current user which are in the trash, DOES NOT load the gist comments
+//	@Tags		User Operations
+//	@Produce	json
+//	@Success	200	{object}	models.TrashedGistArrayWrapper
+//	@Failure	401	{object}	models.ErrorResponseWrapper
+//	@Failure	403	{object}	models.ErrorResponseWrapper
+//	@Failure	500	{object}	models.ErrorResponseWrapper
+//	@Router		/users/me/trash [get]
+func (uc *UserController) GetTrashedGists(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	config, err := initializers.LoadConfig(os.Getenv("API_ENV_CONFIG_PATH"))
+	if err != nil {
+		zap.L().Error(err.Error())
+		utils.SomethingBadHappened(ctx)
+		return
+	}
+	retention := trashRetention(config)
+
+	var gists []models.Gist
+	result := uc.DB.Unscoped().
+		Preload("Files", orderedFiles).
+		Where("username = ? AND deleted_at IS NOT NULL", currentUser.Username).
+		Order("deleted_at DESC").
+		Find(&gists)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	gistsWithoutComments := make([]models.GistWithoutComments, 0, len(gists))
+	for _, gist := range gists {
+		gistsWithoutComments = append(gistsWithoutComments, toGistWithoutComments(gist))
+	}
+	withGistDetails(uc.DB, gistsWithoutComments)
+
+	trashedGists := make([]models.TrashedGist, 0, len(gists))
+	for i, gist := range gists {
+		trashedGists = append(trashedGists, models.TrashedGist{
+			Gist:      gistsWithoutComments[i],
+			DeletedAt: gist.DeletedAt.Time,
+			PurgeAt:   gist.DeletedAt.Time.Add(retention),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, models.TrashedGistArrayWrapper{Gists: trashedGists})
+}
+
+//	@Summary	Restore a gist of the current user from the trash
+//	@Tags		User Operations
+//	@Produce	json
+//	@Param		gistId	path		string	true	"The ID of the gist to restore"
+//	@Success	200		{object}	models.GistWithoutCommentsWrapper
+//	@Failure	400		{object}	models.ErrorResponseWrapper
+//	@Failure	401		{object}	models.ErrorResponseWrapper
+//	@Failure	403		{object}	models.ErrorResponseWrapper
+//	@Failure	404		{object}	models.ErrorResponseWrapper
+//	@Failure	409		{object}	models.ErrorResponseWrapper
+//	@Router		/users/gists/{gistId}/restore [patch]
+func (uc *UserController) RestoreGist(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	gistId := ctx.Params.ByName("gistId")
+
+	gist, ok := uc.loadTrashedGist(ctx, currentUser, gistId)
+	if !ok {
+		return
+	}
+
+	// Another gist might have taken the name while this one was in the trash
+	for _, currentUserGist := range currentUser.Gists {
+		if currentUserGist.Name == gist.Name {
+			utils.NewErrorResponse(ctx, http.StatusConflict, "Gist with name: '"+gist.Name+"' already exists")
+			return
+		}
+	}
+
+	// The gist appears in the lists of gists again, their Last-Modified date has to change
+	result := uc.DB.Unscoped().Model(&gist).UpdateColumns(map[string]interface{}{
+		"deleted_at":       nil,
+		"last_activity_at": time.Now(),
+	})
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, result.Error.Error())
+		return
+	}
+	gist.DeletedAt = gorm.DeletedAt{}
+
+	ctx.JSON(http.StatusOK, models.GistWithoutCommentsWrapper{
+		Gist: gistWithDetails(uc.DB, gist),
+	})
+}
+
+//	@Summary	Permanently delete a gist of the current user which is in the trash
+//	@Tags		User Operations
+//	@Param		gistId	path	string	true	"The ID of the gist to purge"
+//	@Success	204
+//	@Failure	400	{object}	models.ErrorResponseWrapper
+//	@Failure	401	{object}	models.ErrorResponseWrapper
+//	@Failure	403	{object}	models.ErrorResponseWrapper
+//	@Failure	404	{object}	models.ErrorResponseWrapper
+//	@Failure	500	{object}	models.ErrorResponseWrapper
+//	@Router		/users/gists/{gistId}/purge [delete]
+func (uc *UserController) PurgeGist(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	gistId := ctx.Params.ByName("gistId")
+
+	gist, ok := uc.loadTrashedGist(ctx, currentUser, gistId)
+	if !ok {
+		return
+	}
+
+	err := uc.DB.Transaction(func(tx *gorm.DB) error {
+		return deleteGistCascade(tx, gist)
+	})
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (uc *UserController) loadTrashedGist(ctx *gin.Context, currentUser models.User, gistId string) (models.Gist, bool) {
+	var gist models.Gist
+
+	parsedGistId, err := uuid.Parse(gistId)
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid gist id")
+		return gist, false
+	}
+
+	result := uc.DB.Unscoped().
+		Preload("Files", orderedFiles).
+		First(&gist, "id = ? AND deleted_at IS NOT NULL", parsedGistId)
+	if result.Error != nil || gist.Username != currentUser.Username {
+		utils.NewErrorResponse(ctx, http.StatusNotFound, "gist does not exist in the trash")
+		return gist, false
+	}
+
+	return gist, true
+}
+
+// StartTrashPurger permanently deletes gists which have been in the trash for longer than the retention period. The
+// purge runs once on start and then periodically in the background.
+func StartTrashPurger(DB *gorm.DB, config initializers.Config) {
+	retention := trashRetention(config)
+
+	go func() {
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			purgeExpiredGists(DB, retention)
+			<-ticker.C
+		}
+	}()
+}
+
+func purgeExpiredGists(DB *gorm.DB, retention time.Duration) {
+	var gists []models.Gist
+	result := DB.Unscoped().Find(&gists, "deleted_at < ?", time.Now().Add(-retention))
+	if result.Error != nil {
+		zap.L().Error("could not load expired gists", zap.Error(result.Error))
+		return
+	}
+
+	for _, gist := range gists {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			return deleteGistCascade(tx, gist)
+		})
+		if err != nil {
+			zap.L().Error("could not purge gist", zap.String("gistId", gist.ID.String()), zap.Error(err))
+			continue
+		}
+		zap.L().Info("purged gist", zap.String("gistId", gist.ID.String()))
+	}
+}
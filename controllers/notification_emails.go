@@ -0,0 +1,394 @@
+package controllers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/initializers"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	emailCategoryComments = "comments"
+	emailCategoryStars    = "stars"
+	emailCategoryFollows  = "follows"
+	emailCategoryMentions = "mentions"
+	emailCategoryDigest   = "digest"
+	emailCategoryAll      = "all"
+)
+
+const notificationMailInterval = time.Minute
+
+const notificationMailBatchSize = 100
+
+// emailCategory returns the email preference deciding whether a notification of the given type is emailed
+func emailCategory(notificationType string) string {
+	switch notificationType {
+	case notificationComment, notificationReply:
+		return emailCategoryComments
+	case notificationStar:
+		return emailCategoryStars
+	case notificationFollow:
+		return emailCategoryFollows
+	case notificationMention:
+		return emailCategoryMentions
+	}
+	return ""
+}
+
+func emailCategoryEnabled(preferences models.EmailPreferences, category string) bool {
+	switch category {
+	case emailCategoryComments:
+		return preferences.Comments
+	case emailCategoryStars:
+		return preferences.Stars
+	case emailCategoryFollows:
+		return preferences.Follows
+	case emailCategoryMentions:
+		return preferences.Mentions
+	}
+	return false
+}
+
+func loadEmailPreferences(db *gorm.DB, username string) (models.EmailPreferences, error) {
+	preferences := models.EmailPreferences{Username: username, Digest: digestOff}
+	result := db.Limit(1).Find(&preferences, "username = ?", username)
+	return preferences, result.Error
+}
+
+//	@Summary	Get which notifications of the current user are also sent by email
+//	@Tags		User Operations
+//	@Produce	json
+//	@Success	200	{object}	models.EmailPreferencesWrapper
+//	@Failure	401	{object}	models.ErrorResponseWrapper
+//	@Failure	403	{object}	models.ErrorResponseWrapper
+//	@Failure	500	{object}	models.ErrorResponseWrapper
+//	@Router		/users/me/email-preferences [get]
+func (uc *UserController) GetEmailPreferences(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	preferences, err := loadEmailPreferences(uc.DB, currentUser.Username)
+	if err != nil {
+		zap.L().Error(err.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.EmailPreferencesWrapper{EmailPreferences: preferences})
+}
+
+//	@Summary	Choose which notifications of the current user are also sent by email, omitted fields are left unchanged
+//	@Tags		User Operations
+//	@Accept		json
+//	@Produce	json
+//	@Param		UpdateEmailPreferencesInput	body		models.UpdateEmailPreferencesRequest	true	"The Input for updating email preferences"
+//	@Success	200							{object}	models.EmailPreferencesWrapper
+//	@Failure	400							{object}	models.ErrorResponseWrapper
+//	@Failure	401							{object}	models.ErrorResponseWrapper
+//	@Failure	403							{object}	models.ErrorResponseWrapper
+//	@Router		/users/me/email-preferences [patch]
+func (uc *UserController) UpdateEmailPreferences(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	var payload *models.UpdateEmailPreferencesRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	preferences, err := loadEmailPreferences(uc.DB, currentUser.Username)
+	if err != nil {
+		zap.L().Error(err.Error())
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if payload.Comments != nil {
+		preferences.Comments = *payload.Comments
+	}
+	if payload.Stars != nil {
+		preferences.Stars = *payload.Stars
+	}
+	if payload.Follows != nil {
+		preferences.Follows = *payload.Follows
+	}
+	if payload.Mentions != nil {
+		preferences.Mentions = *payload.Mentions
+	}
+	if payload.Digest != nil && *payload.Digest != preferences.Digest {
+		// The first digest covers the activity from now on
+		if preferences.Digest == digestOff {
+			now := time.Now()
+			preferences.LastDigestAt = &now
+		}
+		preferences.Digest = *payload.Digest
+	}
+
+	result := uc.DB.Save(&preferences)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, result.Error.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.EmailPreferencesWrapper{EmailPreferences: preferences})
+}
+
+// unsubscribePage asks to confirm unsubscribing, the form posts back to the link that was opened
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>Unsubscribe</title>
+</head>
+<body>
+	<p>Stop sending {{.Category}} emails to @{{.Username}}?</p>
+	<form method="post">
+		<button type="submit">Unsubscribe</button>
+	</form>
+</body>
+</html>
+`))
+
+// loadUnsubscribeRequest verifies the token of an unsubscribe link and returns the user and the email category it
+// stops. If the token is invalid an error response is written and false is returned.
+func (uc *UserController) loadUnsubscribeRequest(ctx *gin.Context) (models.User, string, bool) {
+	config, err := initializers.LoadConfig(os.Getenv("API_ENV_CONFIG_PATH"))
+	if err != nil {
+		zap.L().Error(err.Error())
+		utils.SomethingBadHappened(ctx)
+		return models.User{}, "", false
+	}
+
+	username, category, err := utils.ParseUnsubscribeToken(config.UnsubscribeSecret, ctx.Query("token"))
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return models.User{}, "", false
+	}
+
+	switch category {
+	case emailCategoryComments, emailCategoryStars, emailCategoryFollows, emailCategoryMentions, emailCategoryDigest,
+		emailCategoryAll:
+	default:
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid unsubscribe token")
+		return models.User{}, "", false
+	}
+
+	var user models.User
+	result := uc.DB.First(&user, "username = ?", username)
+	if result.Error != nil {
+		utils.NewErrorResponse(ctx, http.StatusNotFound, "user does not exist")
+		return models.User{}, "", false
+	}
+
+	return user, category, true
+}
+
+//	@Summary		Show the confirmation page of the signed unsubscribe link from an email
+//	@Description	Does not change anything, mail scanners open links on their own. The page posts the confirmation.
+//	@Tags			User Operations
+//	@Produce		html
+//	@Param			token	query		string	true	"The token from the unsubscribe link"
+//	@Success		200		{string}	string	"The confirmation page"
+//	@Failure		400		{object}	models.ErrorResponseWrapper
+//	@Failure		404		{object}	models.ErrorResponseWrapper
+//	@Failure		500		{object}	models.ErrorResponseWrapper
+//	@Router			/users/unsubscribe [get]
+func (uc *UserController) ConfirmUnsubscribe(ctx *gin.Context) {
+	user, category, ok := uc.loadUnsubscribeRequest(ctx)
+	if !ok {
+		return
+	}
+
+	var page bytes.Buffer
+	err := unsubscribePage.Execute(&page, map[string]string{"Category": category, "Username": user.Username})
+	if err != nil {
+		zap.L().Error(err.Error())
+		utils.SomethingBadHappened(ctx)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+//	@Summary		Stop notification emails using the signed link from an email, does not require authentication
+//	@Description	Also the target of the one-click unsubscribe of mail clients, see RFC 8058.
+//	@Tags			User Operations
+//	@Produce		json
+//	@Param			token	query		string	true	"The token from the unsubscribe link"
+//	@Success		200		{object}	models.SuccessResponseWrapper
+//	@Failure		400		{object}	models.ErrorResponseWrapper
+//	@Failure		404		{object}	models.ErrorResponseWrapper
+//	@Failure		500		{object}	models.ErrorResponseWrapper
+//	@Router			/users/unsubscribe [post]
+func (uc *UserController) Unsubscribe(ctx *gin.Context) {
+	user, category, ok := uc.loadUnsubscribeRequest(ctx)
+	if !ok {
+		return
+	}
+
+	preferences, err := loadEmailPreferences(uc.DB, user.Username)
+	if err != nil {
+		zap.L().Error(err.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	switch category {
+	case emailCategoryComments:
+		preferences.Comments = false
+	case emailCategoryStars:
+		preferences.Stars = false
+	case emailCategoryFollows:
+		preferences.Follows = false
+	case emailCategoryMentions:
+		preferences.Mentions = false
+	case emailCategoryDigest:
+		preferences.Digest = digestOff
+	case emailCategoryAll:
+		preferences.Comments = false
+		preferences.Stars = false
+		preferences.Follows = false
+		preferences.Mentions = false
+		preferences.Digest = digestOff
+	}
+
+	result := uc.DB.Save(&preferences)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	utils.NewSuccessResponse(ctx, http.StatusOK, "successfully unsubscribed from "+category+" emails")
+}
+
+// StartNotificationMailer periodically emails the new notifications to the users who opted in for them
+func StartNotificationMailer(DB *gorm.DB, config initializers.Config) {
+	go func() {
+		ticker := time.NewTicker(notificationMailInterval)
+		defer ticker.Stop()
+
+		for {
+			// A full batch means more notifications might be pending, keep going without waiting
+			if sendPendingNotificationEmails(DB, config) < notificationMailBatchSize {
+				<-ticker.C
+			}
+		}
+	}()
+}
+
+// sendPendingNotificationEmails handles one batch of notifications which have not been considered for an email yet and
+// returns the size of the batch
+func sendPendingNotificationEmails(DB *gorm.DB, config initializers.Config) int {
+	var notifications []models.Notification
+	result := DB.Order("created_at ASC").
+		Limit(notificationMailBatchSize).
+		Find(&notifications, "email_pending = ?", true)
+	if result.Error != nil {
+		zap.L().Error("could not load pending notifications", zap.Error(result.Error))
+		return 0
+	}
+	if len(notifications) == 0 {
+		return 0
+	}
+
+	ids := make([]uuid.UUID, 0, len(notifications))
+	usernames := make([]string, 0, len(notifications))
+	for _, notification := range notifications {
+		ids = append(ids, notification.ID)
+		usernames = append(usernames, notification.Username)
+	}
+
+	// Notifications are only emailed once, even if sending fails
+	result = DB.Model(&models.Notification{}).Where("id IN ?", ids).UpdateColumn("email_pending", false)
+	if result.Error != nil {
+		zap.L().Error("could not update pending notifications", zap.Error(result.Error))
+		return 0
+	}
+
+	var users []models.User
+	result = DB.Find(&users, "username IN ?", usernames)
+	if result.Error != nil {
+		zap.L().Error("could not load users to notify", zap.Error(result.Error))
+		return len(notifications)
+	}
+	usersByUsername := make(map[string]models.User, len(users))
+	for _, user := range users {
+		usersByUsername[user.Username] = user
+	}
+
+	var preferences []models.EmailPreferences
+	result = DB.Find(&preferences, "username IN ?", usernames)
+	if result.Error != nil {
+		zap.L().Error("could not load email preferences", zap.Error(result.Error))
+		return len(notifications)
+	}
+	preferencesByUsername := make(map[string]models.EmailPreferences, len(preferences))
+	for _, preference := range preferences {
+		preferencesByUsername[preference.Username] = preference
+	}
+
+	for _, notification := range notifications {
+		user, userExists := usersByUsername[notification.Username]
+		category := emailCategory(notification.Type)
+		if !userExists || !user.Verified || !emailCategoryEnabled(preferencesByUsername[user.Username], category) {
+			continue
+		}
+
+		emailData, err := notificationEmailData(config, user, notification, category)
+		if err == nil {
+			err = utils.SendEmail(user.Email, &emailData, "notification.html")
+		}
+		if err != nil {
+			zap.L().Error("could not send notification email",
+				zap.String("notificationId", notification.ID.String()), zap.Error(err))
+		}
+	}
+
+	return len(notifications)
+}
+
+func notificationEmailData(config initializers.Config, user models.User, notification models.Notification,
+	category string) (utils.EmailData, error) {
+	var message string
+	switch notification.Type {
+	case notificationComment:
+		message = "@" + notification.Actor + " commented on your gist"
+	case notificationReply:
+		message = "@" + notification.Actor + " replied to your comment"
+	case notificationMention:
+		message = "@" + notification.Actor + " mentioned you in a comment"
+	case notificationStar:
+		message = "@" + notification.Actor + " starred your gist"
+	case notificationFollow:
+		message = "@" + notification.Actor + " started following you"
+	}
+
+	link := config.ClientOrigin + "/" + notification.Actor
+	if notification.GistID != nil {
+		link = config.ClientOrigin + "/gists/" + notification.GistID.String()
+	}
+
+	token, err := utils.SignUnsubscribeToken(config.UnsubscribeSecret, user.Username, category)
+	if err != nil {
+		return utils.EmailData{}, err
+	}
+
+	return utils.EmailData{
+		URL:            link,
+		FirstName:      user.FirstName,
+		Subject:        "[GitHub-Gist-Clone] " + message,
+		Message:        message + ".",
+		UnsubscribeURL: config.ServerOrigin + "/api/users/unsubscribe?token=" + url.QueryEscape(token),
+	}, nil
+}
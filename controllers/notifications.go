@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	notificationMention = "mention"
+	notificationComment = "comment"
+	notificationReply   = "reply"
+	notificationFollow  = "follow"
+	notificationStar    = "star"
+)
+
+// notify stores the notifications, every notification is delivered to the inbox of its user
+func notify(tx *gorm.DB, notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	for i := range notifications {
+		notifications[i].EmailPending = true
+	}
+
+	result := tx.Create(&notifications)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return result.Error
+	}
+	return nil
+}
+
+// syncCommentMentions updates the mention links of a comment to the users mentioned in its content and notifies the
+// users who were not mentioned before. Mentions of unknown users, of the author and of users who cannot see the gist
+// are ignored. The usernames of the notified users are returned.
+func syncCommentMentions(tx *gorm.DB, gist models.Gist, comment models.Comment) ([]string, error) {
+	notified := make([]string, 0)
+
+	var existingMentions []models.CommentMention
+	result := tx.Find(&existingMentions, "comment_id = ?", comment.CommentID)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return notified, result.Error
+	}
+
+	alreadyMentioned := make(map[string]bool)
+	for _, mention := range existingMentions {
+		alreadyMentioned[mention.Username] = true
+	}
+
+	mentioned := make(map[string]bool)
+	newlyMentioned := make([]string, 0)
+	for _, username := range utils.ParseMentions(comment.Content) {
+		mentioned[username] = true
+		if !alreadyMentioned[username] && username != comment.Username {
+			newlyMentioned = append(newlyMentioned, username)
+		}
+	}
+
+	// Mentions removed by editing the comment
+	removedMentions := make([]string, 0)
+	for username := range alreadyMentioned {
+		if !mentioned[username] {
+			removedMentions = append(removedMentions, username)
+		}
+	}
+	if len(removedMentions) != 0 {
+		result = tx.Where("comment_id = ? AND username IN ?", comment.CommentID, removedMentions).
+			Delete(&models.CommentMention{})
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return notified, result.Error
+		}
+	}
+
+	if len(newlyMentioned) == 0 {
+		return notified, nil
+	}
+
+	var users []models.User
+	result = tx.Find(&users, "username IN ?", newlyMentioned)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return notified, result.Error
+	}
+
+	mentions := make([]models.CommentMention, 0, len(users))
+	notifications := make([]models.Notification, 0, len(users))
+	for i := range users {
+		policy := &gistPolicy{db: tx, viewer: &users[i]}
+		if !policy.canView(gist) {
+			continue
+		}
+
+		mentions = append(mentions, models.CommentMention{
+			CommentID: comment.CommentID,
+			Username:  users[i].Username,
+		})
+		notifications = append(notifications, models.Notification{
+			Username:  users[i].Username,
+			Actor:     comment.Username,
+			Type:      notificationMention,
+			GistID:    &gist.ID,
+			CommentID: &comment.CommentID,
+			CreatedAt: comment.UpdatedAt,
+		})
+		notified = append(notified, users[i].Username)
+	}
+
+	if len(mentions) != 0 {
+		result = tx.Create(&mentions)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return notified, result.Error
+		}
+	}
+
+	return notified, notify(tx, notifications)
+}
+
+// notifyCommentRecipients notifies the owner of the gist about a new comment and the author of the parent comment
+// about a reply, users in alreadyNotified (e.g. because they were mentioned) are skipped
+func notifyCommentRecipients(tx *gorm.DB, gist models.Gist, comment models.Comment, alreadyNotified []string) error {
+	skip := map[string]bool{comment.Username: true}
+	for _, username := range alreadyNotified {
+		skip[username] = true
+	}
+
+	notifications := make([]models.Notification, 0, 2)
+	if !skip[gist.Username] {
+		skip[gist.Username] = true
+		notifications = append(notifications, models.Notification{
+			Username:  gist.Username,
+			Actor:     comment.Username,
+			Type:      notificationComment,
+			GistID:    &gist.ID,
+			CommentID: &comment.CommentID,
+			CreatedAt: comment.CreatedAt,
+		})
+	}
+
+	if comment.ParentID != nil {
+		var parentAuthor models.User
+		result := tx.
+			Joins("JOIN comments ON comments.username = users.username").
+			Where("comments.comment_id = ?", *comment.ParentID).
+			Limit(1).
+			Find(&parentAuthor)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+
+		// The author of the parent comment might no longer exist or have lost access to a private gist since
+		policy := &gistPolicy{db: tx, viewer: &parentAuthor}
+		if result.RowsAffected != 0 && !skip[parentAuthor.Username] && policy.canView(gist) {
+			notifications = append(notifications, models.Notification{
+				Username:  parentAuthor.Username,
+				Actor:     comment.Username,
+				Type:      notificationReply,
+				GistID:    &gist.ID,
+				CommentID: &comment.CommentID,
+				CreatedAt: comment.CreatedAt,
+			})
+		}
+	}
+
+	return notify(tx, notifications)
+}
+
+//	@Summary	Get the notifications of the current user
+//	@Tags		User Operations
+//	@Produce	json
+//	@Param		unread		query		bool	false	"Only return unread notifications"
+//	@Param		direction	query		string	false	"asc or desc (default), notifications are sorted by the time they were created"
+//	@Param		limit		query		int		false	"The number of notifications to return, between 1 and 100, defaults to 30"
+//	@Param		cursor		query		string	false	"The cursor of the next or previous page, taken from the links"
+//	@Success	200			{object}	models.NotificationInboxWrapper
+//	@Failure	400			{object}	models.ErrorResponseWrapper
+//	@Failure	401			{object}	models.ErrorResponseWrapper
+//	@Failure	403			{object}	models.ErrorResponseWrapper
+//	@Failure	500			{object}	models.ErrorResponseWrapper
+//	@Router		/users/me/notifications [get]
+func (uc *UserController) GetNotifications(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	page, ok := parsePageRequest(ctx, notificationSorts, "created", sortDescending, "notifications.id")
+	if !ok {
+		return
+	}
+
+	query := uc.DB.Where("notifications.username = ?", currentUser.Username)
+	if ctx.Query("unread") == "true" {
+		query = query.Where("notifications.read = ?", false)
+	}
+
+	var notifications []models.Notification
+	result := page.apply(query).Find(&notifications)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	notifications, links := paginate(ctx, page, notifications, func(notification models.Notification) (interface{}, string) {
+		return notification.CreatedAt, notification.ID.String()
+	})
+	if notifications == nil {
+		notifications = make([]models.Notification, 0)
+	}
+
+	var unreadCount int64
+	result = uc.DB.Model(&models.Notification{}).
+		Where("username = ? AND read = ?", currentUser.Username, false).
+		Count(&unreadCount)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NotificationInboxWrapper{
+		Inbox: models.NotificationInbox{
+			UnreadCount:   unreadCount,
+			Notifications: notifications,
+		},
+		Links: links,
+	})
+}
+
+//	@Summary	Mark a notification of the current user as read
+//	@Tags		User Operations
+//	@Produce	json
+//	@Param		notificationId	path		string	true	"The ID of the notification"
+//	@Success	200				{object}	models.SuccessResponseWrapper
+//	@Failure	400				{object}	models.ErrorResponseWrapper
+//	@Failure	401				{object}	models.ErrorResponseWrapper
+//	@Failure	403				{object}	models.ErrorResponseWrapper
+//	@Failure	404				{object}	models.ErrorResponseWrapper
+//	@Router		/users/me/notifications/{notificationId}/read [patch]
+func (uc *UserController) MarkNotificationRead(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	notificationId, err := uuid.Parse(ctx.Params.ByName("notificationId"))
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid notification id")
+		return
+	}
+
+	result := uc.DB.Model(&models.Notification{}).
+		Where("id = ? AND username = ?", notificationId, currentUser.Username).
+		UpdateColumn("read", true)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.NewErrorResponse(ctx, http.StatusNotFound, "notification does not exist")
+		return
+	}
+
+	utils.NewSuccessResponse(ctx, http.StatusOK, "successfully marked notification as read")
+}
+
+//	@Summary	Mark all notifications of the current user as read
+//	@Tags		User Operations
+//	@Produce	json
+//	@Success	200	{object}	models.SuccessResponseWrapper
+//	@Failure	400	{object}	models.ErrorResponseWrapper
+//	@Failure	401	{object}	models.ErrorResponseWrapper
+//	@Failure	403	{object}	models.ErrorResponseWrapper
+//	@Router		/users/me/notifications/read [patch]
+func (uc *UserController) MarkAllNotificationsRead(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	result := uc.DB.Model(&models.Notification{}).
+		Where("username = ? AND read = ?", currentUser.Username, false).
+		UpdateColumn("read", true)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, result.Error.Error())
+		return
+	}
+
+	utils.NewSuccessResponse(ctx, http.StatusOK, "successfully marked all notifications as read")
+}
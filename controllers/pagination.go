@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageLimit = 30
+	maxPageLimit     = 100
+)
+
+const (
+	sortAscending  = "asc"
+	sortDescending = "desc"
+)
+
+// pageSort is a column a paginated list can be sorted by, isTime tells whether it holds times or integers
+type pageSort struct {
+	column string
+	isTime bool
+}
+
+// The sorts of the paginated lists, the keys are the values of the sort query parameter
+var (
+	gistSorts = map[string]pageSort{
+		"created": {column: "gists.created_at", isTime: true},
+		"updated": {column: "gists.updated_at", isTime: true},
+		"stars":   {column: "gists.star_count"},
+	}
+	starredGistSorts = map[string]pageSort{
+		"created": {column: "stars.created_at", isTime: true},
+		"updated": {column: "gists.updated_at", isTime: true},
+		"stars":   {column: "gists.star_count"},
+	}
+	commentSorts = map[string]pageSort{
+		"created": {column: "comments.created_at", isTime: true},
+		"updated": {column: "comments.updated_at", isTime: true},
+	}
+	starSorts = map[string]pageSort{
+		"created": {column: "stars.created_at", isTime: true},
+	}
+	followSorts = map[string]pageSort{
+		"created": {column: "follows.created_at", isTime: true},
+	}
+	userSearchSorts = map[string]pageSort{
+		"followers": {column: "user_metadata.followers"},
+	}
+	feedSorts = map[string]pageSort{
+		"created": {column: "activity_events.created_at", isTime: true},
+	}
+	notificationSorts = map[string]pageSort{
+		"created": {column: "notifications.created_at", isTime: true},
+	}
+)
+
+// The key columns of the paginated lists holding UUIDs, the keys of the cursors must be valid UUIDs for them
+var uuidKeyColumns = map[string]bool{
+	"gists.id":            true,
+	"comments.comment_id": true,
+	"activity_events.id":  true,
+	"notifications.id":    true,
+}
+
+// pageRequest is the pagination shared by the list endpoints: the page starts after the row the opaque cursor points
+// at (or ends before it for previous pages) and the rows are sorted by one of the columns of the list, keyColumn
+// breaks ties. Pages are read with keyset pagination so that rows added or removed meanwhile do not shift them.
+type pageRequest struct {
+	limit       int
+	sortName    string
+	sort        pageSort
+	direction   string
+	keyColumn   string
+	cursor      *utils.PageCursor
+	cursorValue interface{}
+}
+
+// parsePageRequest reads the limit, sort, direction and cursor query parameters. If they are invalid an error response
+// is written and false is returned.
+func parsePageRequest(ctx *gin.Context, sorts map[string]pageSort, defaultSort, defaultDirection, keyColumn string) (*pageRequest, bool) {
+	page := &pageRequest{
+		sortName:  ctx.DefaultQuery("sort", defaultSort),
+		direction: ctx.DefaultQuery("direction", defaultDirection),
+		keyColumn: keyColumn,
+	}
+
+	limit, ok := parsePageLimit(ctx)
+	if !ok {
+		return nil, false
+	}
+	page.limit = limit
+
+	selectedSort, exists := sorts[page.sortName]
+	if !exists {
+		sortNames := make([]string, 0, len(sorts))
+		for sortName := range sorts {
+			sortNames = append(sortNames, sortName)
+		}
+		sort.Strings(sortNames)
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "sort must be one of: "+strings.Join(sortNames, ", "))
+		return nil, false
+	}
+	page.sort = selectedSort
+
+	if page.direction != sortAscending && page.direction != sortDescending {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "direction must be either asc or desc")
+		return nil, false
+	}
+
+	if cursorParam := ctx.Query("cursor"); cursorParam != "" {
+		cursor, err := utils.DecodePageCursor(cursorParam)
+		if err != nil {
+			utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return nil, false
+		}
+		if cursor.Sort != page.sortName || cursor.Direction != page.direction {
+			utils.NewErrorResponse(ctx, http.StatusBadRequest, "the cursor was created for another sort or direction")
+			return nil, false
+		}
+
+		if selectedSort.isTime {
+			page.cursorValue, err = time.Parse(time.RFC3339Nano, cursor.Value)
+		} else {
+			page.cursorValue, err = strconv.Atoi(cursor.Value)
+		}
+		if err == nil && uuidKeyColumns[keyColumn] {
+			_, err = uuid.Parse(cursor.Key)
+		}
+		if err != nil {
+			utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid cursor")
+			return nil, false
+		}
+		page.cursor = &cursor
+	}
+
+	return page, true
+}
+
+// parsePageLimit reads the limit query parameter. If it is invalid an error response is written and false is returned.
+func parsePageLimit(ctx *gin.Context) (int, bool) {
+	limitParam := ctx.Query("limit")
+	if limitParam == "" {
+		return defaultPageLimit, true
+	}
+
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxPageLimit))
+		return 0, false
+	}
+	return limit, true
+}
+
+// apply restricts the query to the rows of the page, one more row than the limit is read to know whether the list
+// continues after the page
+func (p *pageRequest) apply(query *gorm.DB) *gorm.DB {
+	// Previous pages are read backwards from the cursor and reversed afterwards
+	ascending := p.direction == sortAscending
+	if p.cursor != nil && p.cursor.Previous {
+		ascending = !ascending
+	}
+
+	order, operator := "DESC", "<"
+	if ascending {
+		order, operator = "ASC", ">"
+	}
+
+	if p.cursor != nil {
+		query = query.Where(
+			fmt.Sprintf("(%s, %s) %s (?, ?)", p.sort.column, p.keyColumn, operator),
+			p.cursorValue, p.cursor.Key,
+		)
+	}
+
+	return query.
+		Order(fmt.Sprintf("%s %s, %s %s", p.sort.column, order, p.keyColumn, order)).
+		Limit(p.limit + 1)
+}
+
+// cursorAt creates the cursor pointing at a row given its sort value (a time.Time or an int) and key
+func (p *pageRequest) cursorAt(sortValue interface{}, key string, previous bool) string {
+	cursor := utils.PageCursor{
+		Sort:      p.sortName,
+		Direction: p.direction,
+		Key:       key,
+		Previous:  previous,
+	}
+	switch value := sortValue.(type) {
+	case time.Time:
+		cursor.Value = value.Format(time.RFC3339Nano)
+	case int:
+		cursor.Value = strconv.Itoa(value)
+	}
+	return utils.EncodePageCursor(cursor)
+}
+
+// pageLink returns the URL of the current request with the cursor replaced
+func (p *pageRequest) pageLink(ctx *gin.Context, cursor string) string {
+	return requestLink(ctx, map[string]string{
+		"cursor":    cursor,
+		"limit":     strconv.Itoa(p.limit),
+		"sort":      p.sortName,
+		"direction": p.direction,
+	})
+}
+
+// requestLink returns the URL of the current request with the given query parameters replaced
+func requestLink(ctx *gin.Context, params map[string]string) string {
+	link := *ctx.Request.URL
+	query := link.Query()
+	for name, value := range params {
+		query.Set(name, value)
+	}
+	link.RawQuery = query.Encode()
+	return link.RequestURI()
+}
+
+// setLinkHeader sets the Link header of a paginated response, see https://www.rfc-editor.org/rfc/rfc8288
+func setLinkHeader(ctx *gin.Context, links models.PageLinks) {
+	var linkHeader []string
+	if links.Next != "" {
+		linkHeader = append(linkHeader, "<"+links.Next+`>; rel="next"`)
+	}
+	if links.Prev != "" {
+		linkHeader = append(linkHeader, "<"+links.Prev+`>; rel="prev"`)
+	}
+	if len(linkHeader) != 0 {
+		ctx.Header("Link", strings.Join(linkHeader, ", "))
+	}
+}
+
+// gistSortValue returns the cursor values of a gist in a list sorted with gistSorts
+func gistSortValue(p *pageRequest) func(gist models.Gist) (interface{}, string) {
+	return func(gist models.Gist) (interface{}, string) {
+		switch p.sortName {
+		case "updated":
+			return gist.UpdatedAt, gist.ID.String()
+		case "stars":
+			return gist.StarCount, gist.ID.String()
+		default:
+			return gist.CreatedAt, gist.ID.String()
+		}
+	}
+}
+
+// paginate trims the rows read with pageRequest.apply to the page, puts them in the requested order and sets the Link
+// header. cursorOf returns the sort value and the key of a row.
+func paginate[T any](ctx *gin.Context, p *pageRequest, rows []T, cursorOf func(row T) (interface{}, string)) ([]T, models.PageLinks) {
+	hasMore := len(rows) > p.limit
+	if hasMore {
+		rows = rows[:p.limit]
+	}
+
+	previous := p.cursor != nil && p.cursor.Previous
+	if previous {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	// Reading forward there is a previous page when a cursor was used, reading backward there always is a next page
+	hasNext, hasPrevious := hasMore, p.cursor != nil
+	if previous {
+		hasNext, hasPrevious = true, hasMore
+	}
+
+	var links models.PageLinks
+	if hasNext && len(rows) != 0 {
+		sortValue, key := cursorOf(rows[len(rows)-1])
+		links.Next = p.pageLink(ctx, p.cursorAt(sortValue, key, false))
+	}
+	if hasPrevious && len(rows) != 0 {
+		sortValue, key := cursorOf(rows[0])
+		links.Prev = p.pageLink(ctx, p.cursorAt(sortValue, key, true))
+	}
+	setLinkHeader(ctx, links)
+
+	return rows, links
+}
@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newTestContext(target string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	return ctx, recorder
+}
+
+type testRow struct {
+	createdAt time.Time
+	id        string
+}
+
+func testRowCursor(row testRow) (interface{}, string) {
+	return row.createdAt, row.id
+}
+
+func testRows(ids ...string) []testRow {
+	rows := make([]testRow, 0, len(ids))
+	for i, id := range ids {
+		rows = append(rows, testRow{createdAt: time.Date(2023, 1, 1, 0, 0, i, 0, time.UTC), id: id})
+	}
+	return rows
+}
+
+// linkCursor decodes the cursor of a page link, it fails the test if the link does not have one
+func linkCursor(t *testing.T, link string) utils.PageCursor {
+	t.Helper()
+
+	parsedLink, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := utils.DecodePageCursor(parsedLink.Query().Get("cursor"))
+	if err != nil {
+		t.Fatalf("invalid cursor in %s: %v", link, err)
+	}
+	return cursor
+}
+
+func TestParsePageRequest(t *testing.T) {
+	otherSortCursor := utils.EncodePageCursor(utils.PageCursor{Sort: "stars", Direction: sortDescending, Value: "1", Key: "a"})
+	validCursor := utils.EncodePageCursor(utils.PageCursor{
+		Sort:      "created",
+		Direction: sortDescending,
+		Value:     "2023-01-01T00:00:00Z",
+		Key:       "8c1b6a3e-6f4c-4d55-9a43-5b1a8f0e2c7d",
+	})
+	invalidKeyCursor := utils.EncodePageCursor(utils.PageCursor{
+		Sort:      "created",
+		Direction: sortDescending,
+		Value:     "2023-01-01T00:00:00Z",
+		Key:       "not a uuid",
+	})
+
+	tests := []struct {
+		name  string
+		query string
+		valid bool
+	}{
+		{name: "defaults", query: "", valid: true},
+		{name: "all parameters", query: "sort=stars&direction=asc&limit=100", valid: true},
+		{name: "valid cursor", query: "cursor=" + validCursor, valid: true},
+		{name: "limit too small", query: "limit=0"},
+		{name: "limit too large", query: "limit=101"},
+		{name: "limit not a number", query: "limit=ten"},
+		{name: "unknown sort", query: "sort=name"},
+		{name: "unknown direction", query: "direction=up"},
+		{name: "malformed cursor", query: "cursor=%21%21"},
+		{name: "cursor of another sort", query: "cursor=" + otherSortCursor},
+		{name: "cursor key is not a uuid", query: "cursor=" + invalidKeyCursor},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, recorder := newTestContext("/gists?" + test.query)
+			_, ok := parsePageRequest(ctx, gistSorts, "created", sortDescending, "gists.id")
+			if ok != test.valid {
+				t.Fatalf("expected valid to be %t, got %t", test.valid, ok)
+			}
+			if !ok && recorder.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, recorder.Code)
+			}
+		})
+	}
+
+	ctx, _ := newTestContext("/gists")
+	page, _ := parsePageRequest(ctx, gistSorts, "created", sortDescending, "gists.id")
+	if page.limit != defaultPageLimit || page.sortName != "created" || page.direction != sortDescending || page.cursor != nil {
+		t.Errorf("unexpected default page: %+v", page)
+	}
+}
+
+func TestPageRequestApply(t *testing.T) {
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1 user=test dbname=test port=1"), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "first page reads one more row than the limit",
+			query:    "limit=2",
+			expected: []string{"ORDER BY gists.created_at DESC, gists.id DESC", "LIMIT 3"},
+		},
+		{
+			name: "next page starts after the cursor",
+			query: "direction=asc&cursor=" + utils.EncodePageCursor(utils.PageCursor{
+				Sort:      "created",
+				Direction: sortAscending,
+				Value:     "2023-01-01T00:00:00Z",
+				Key:       "8c1b6a3e-6f4c-4d55-9a43-5b1a8f0e2c7d",
+			}),
+			expected: []string{
+				"(gists.created_at, gists.id) > ('2023-01-01 00:00:00', '8c1b6a3e-6f4c-4d55-9a43-5b1a8f0e2c7d')",
+				"ORDER BY gists.created_at ASC, gists.id ASC",
+				"LIMIT 31",
+			},
+		},
+		{
+			name: "previous page is read backwards",
+			query: "direction=asc&cursor=" + utils.EncodePageCursor(utils.PageCursor{
+				Sort:      "created",
+				Direction: sortAscending,
+				Value:     "2023-01-01T00:00:00Z",
+				Key:       "8c1b6a3e-6f4c-4d55-9a43-5b1a8f0e2c7d",
+				Previous:  true,
+			}),
+			expected: []string{
+				"(gists.created_at, gists.id) < ('2023-01-01 00:00:00', '8c1b6a3e-6f4c-4d55-9a43-5b1a8f0e2c7d')",
+				"ORDER BY gists.created_at DESC, gists.id DESC",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, _ := newTestContext("/gists?" + test.query)
+			page, ok := parsePageRequest(ctx, gistSorts, "created", sortDescending, "gists.id")
+			if !ok {
+				t.Fatal("invalid page request")
+			}
+
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return page.apply(tx.Model(&models.Gist{})).Find(&[]models.Gist{})
+			})
+			for _, expected := range test.expected {
+				if !strings.Contains(sql, expected) {
+					t.Errorf("expected %q in %s", expected, sql)
+				}
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name         string
+		cursor       *utils.PageCursor
+		rows         []testRow
+		expectedRows []string
+		next         string
+		prev         string
+	}{
+		{
+			name:         "first page of a longer list",
+			rows:         testRows("a", "b", "c"),
+			expectedRows: []string{"a", "b"},
+			next:         "b",
+		},
+		{
+			name:         "only page",
+			rows:         testRows("a", "b"),
+			expectedRows: []string{"a", "b"},
+		},
+		{
+			name:         "empty list",
+			rows:         testRows(),
+			expectedRows: []string{},
+		},
+		{
+			name:         "middle page",
+			cursor:       &utils.PageCursor{Key: "z"},
+			rows:         testRows("a", "b", "c"),
+			expectedRows: []string{"a", "b"},
+			next:         "b",
+			prev:         "a",
+		},
+		{
+			name:         "last page",
+			cursor:       &utils.PageCursor{Key: "z"},
+			rows:         testRows("a"),
+			expectedRows: []string{"a"},
+			prev:         "a",
+		},
+		{
+			name:         "previous page in the middle of the list",
+			cursor:       &utils.PageCursor{Key: "z", Previous: true},
+			rows:         testRows("c", "b", "a"),
+			expectedRows: []string{"b", "c"},
+			next:         "c",
+			prev:         "b",
+		},
+		{
+			name:         "previous page at the start of the list",
+			cursor:       &utils.PageCursor{Key: "z", Previous: true},
+			rows:         testRows("b", "a"),
+			expectedRows: []string{"a", "b"},
+			next:         "b",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, recorder := newTestContext("/gists?limit=2&tag=go")
+			page := &pageRequest{
+				limit:     2,
+				sortName:  "created",
+				sort:      gistSorts["created"],
+				direction: sortAscending,
+				keyColumn: "gists.id",
+				cursor:    test.cursor,
+			}
+
+			rows, links := paginate(ctx, page, test.rows, testRowCursor)
+
+			ids := make([]string, 0, len(rows))
+			for _, row := range rows {
+				ids = append(ids, row.id)
+			}
+			if strings.Join(ids, ",") != strings.Join(test.expectedRows, ",") {
+				t.Errorf("expected rows %v, got %v", test.expectedRows, ids)
+			}
+
+			for _, link := range []struct {
+				rel      string
+				url      string
+				key      string
+				previous bool
+			}{
+				{rel: "next", url: links.Next, key: test.next},
+				{rel: "prev", url: links.Prev, key: test.prev, previous: true},
+			} {
+				if link.key == "" {
+					if link.url != "" {
+						t.Errorf("expected no %s link, got %s", link.rel, link.url)
+					}
+					continue
+				}
+				if link.url == "" {
+					t.Fatalf("expected a %s link", link.rel)
+				}
+
+				cursor := linkCursor(t, link.url)
+				if cursor.Key != link.key || cursor.Previous != link.previous {
+					t.Errorf("expected the %s link to point at %s, got %+v", link.rel, link.key, cursor)
+				}
+				if !strings.Contains(link.url, "tag=go") {
+					t.Errorf("expected the %s link to keep the other query parameters, got %s", link.rel, link.url)
+				}
+				if !strings.Contains(recorder.Header().Get("Link"), "<"+link.url+`>; rel="`+link.rel+`"`) {
+					t.Errorf("expected the %s link in the Link header, got %s", link.rel, recorder.Header().Get("Link"))
+				}
+			}
+		})
+	}
+}
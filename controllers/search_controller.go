@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type SearchController struct {
+	DB *gorm.DB
+}
+
+func NewSearchController(DB *gorm.DB) SearchController {
+	return SearchController{
+		DB: DB,
+	}
+}
+
+// Only the beginning of large files is indexed for full-text search, a tsvector cannot be larger than 1MB
+const searchedContentLength = 100000
+
+// The 'simple' text search configuration neither stems words nor drops stop words, which suits code better than a
+// language specific configuration
+const searchQuery = "websearch_to_tsquery('simple', ?)"
+
+// Markers put around the matching words by ts_headline, replaced with <mark> elements once the text is HTML escaped
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+var (
+	titleHighlightOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true"
+	snippetOptions        = "StartSel=" + highlightStart + ", StopSel=" + highlightStop +
+		", MaxFragments=3, MaxWords=30, MinWords=10, FragmentDelimiter=\" ... \""
+)
+
+// gistSearchVector is the document of the title and the name of a gist, the title weighs the most. prefix qualifies
+// the columns, it is empty in the index definition.
+func gistSearchVector(prefix string) string {
+	return fmt.Sprintf("(setweight(to_tsvector('simple', %[1]stitle), 'A') || setweight(to_tsvector('simple', %[1]sname), 'B'))", prefix)
+}
+
+// fileSearchVector is the document of a file of a gist
+func fileSearchVector(prefix string) string {
+	return fmt.Sprintf("(setweight(to_tsvector('simple', %[1]sfilename), 'B') || setweight(to_tsvector('simple', left(%[1]scontent, %[2]d)), 'C'))",
+		prefix, searchedContentLength)
+}
+
+// CreateSearchIndexes creates the GIN indexes used by the gist search, AutoMigrate cannot create expression indexes.
+// Queries must use the exact same expressions for the indexes to be used. The trigram indexes speed up the substring
+// and regular expression matching of the code search, and the prefix matching of the user search. They need the
+// pg_trgm extension, when it cannot be created (e.g. the database user is not allowed to) the searches still work
+// without them.
+func CreateSearchIndexes(DB *gorm.DB) error {
+	statements := []string{
+		"CREATE INDEX IF NOT EXISTS idx_gists_search ON gists USING GIN (" + gistSearchVector("") + ")",
+		"CREATE INDEX IF NOT EXISTS idx_gist_contents_search ON gist_contents USING GIN (" + fileSearchVector("") + ")",
+	}
+	for _, statement := range statements {
+		if err := DB.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	trigramStatements := []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"CREATE INDEX IF NOT EXISTS idx_gist_contents_content_trgm ON gist_contents USING GIN (content gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_users_search_trgm ON users USING GIN (username gin_trgm_ops, first_name gin_trgm_ops, last_name gin_trgm_ops)",
+	}
+	for _, statement := range trigramStatements {
+		if err := DB.Exec(statement).Error; err != nil {
+			zap.L().Warn("Trigram indexes not created, code and user searches will scan every row", zap.Error(err))
+			return nil
+		}
+	}
+	return nil
+}
+
+type gistSearchRow struct {
+	ID             uuid.UUID
+	Username       string
+	Name           string
+	Title          string
+	TitleHighlight string
+	Private        bool
+	StarCount      int
+	UpdatedAt      time.Time
+	Filename       string
+	Language       string
+	Snippet        string
+	Rank           float64
+	TotalCount     int64
+}
+
+// renderHighlight escapes text highlighted by ts_headline and marks the matching words
+func renderHighlight(text string) string {
+	text = html.EscapeString(text)
+	text = strings.ReplaceAll(text, highlightStart, "<mark>")
+	return strings.ReplaceAll(text, highlightStop, "</mark>")
+}
+
+// parseSearchOffset reads the cursor of a search, it holds the number of results before the page. If it is invalid an
+// error response is written and false is returned.
+func parseSearchOffset(ctx *gin.Context) (int, bool) {
+	cursorParam := ctx.Query("cursor")
+	if cursorParam == "" {
+		return 0, true
+	}
+
+	cursor, err := utils.DecodePageCursor(cursorParam)
+	if err == nil && cursor.Sort == "rank" {
+		offset, err := strconv.Atoi(cursor.Value)
+		if err == nil && offset >= 0 {
+			return offset, true
+		}
+	}
+
+	utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid cursor")
+	return 0, false
+}
+
+// searchPageLinks returns the links to the pages around the page of a search starting at offset
+func searchPageLinks(ctx *gin.Context, offset, limit int, totalCount int64) models.PageLinks {
+	link := func(offset int) string {
+		return requestLink(ctx, map[string]string{
+			"cursor": utils.EncodePageCursor(utils.PageCursor{Sort: "rank", Value: strconv.Itoa(offset)}),
+			"limit":  strconv.Itoa(limit),
+		})
+	}
+
+	var links models.PageLinks
+	if int64(offset+limit) < totalCount {
+		links.Next = link(offset + limit)
+	}
+	if offset > 0 {
+		previousOffset := offset - limit
+		if previousOffset < 0 {
+			previousOffset = 0
+		}
+		links.Prev = link(previousOffset)
+	}
+	setLinkHeader(ctx, links)
+
+	return links
+}
+
+//	@Summary		Search the gists visible to the current user, best matches first
+//	@Description	Matches the title, the name, the filenames and the content of the files. The query supports "quoted
+//	@Description	phrases", OR and -excluded words. Only the first 100000 characters of a file are searched.
+//	@Tags			Search
+//	@Produce		json
+//	@Param			q			query		string	true	"The search query"
+//	@Param			user		query		string	false	"Only search the gists of this user"
+//	@Param			language	query		string	false	"Only search the files in this language, e.g. Go"
+//	@Param			limit		query		int		false	"The number of results to return, between 1 and 100, defaults to 30"
+//	@Param			cursor		query		string	false	"The cursor of the next or previous page, taken from the links"
+//	@Success		200			{object}	models.GistSearchResultsWrapper
+//	@Failure		400			{object}	models.ErrorResponseWrapper
+//	@Failure		500			{object}	models.ErrorResponseWrapper
+//	@Router			/search/gists [get]
+func (sc *SearchController) SearchGists(ctx *gin.Context) {
+	query := strings.TrimSpace(ctx.Query("q"))
+	if query == "" {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	limit, ok := parsePageLimit(ctx)
+	if !ok {
+		return
+	}
+	offset, ok := parseSearchOffset(ctx)
+	if !ok {
+		return
+	}
+
+	gistVector, fileVector := gistSearchVector("gists."), fileSearchVector("gist_contents.")
+	matchingGists := sc.DB.Table("gists").Select("id").Where(gistVector+" @@ "+searchQuery, query)
+	matchingFiles := sc.DB.Table("gist_contents").Select("id").Where(fileVector+" @@ "+searchQuery, query)
+
+	// The best matching file of every matching gist, a gist matches when its title or name or one of its files does
+	matches := sc.DB.Table("gist_contents").
+		Select("DISTINCT ON (gists.id) gists.id AS gist_id, gist_contents.id AS file_id, ts_rank("+
+			gistVector+" || "+fileVector+", "+searchQuery+") AS rank", query).
+		Joins("JOIN gists ON gists.id = gist_contents.gist_id").
+		Where("gists.deleted_at IS NULL").
+		Where("(gist_contents.id IN (?) OR gists.id IN (?))", matchingFiles, matchingGists).
+		Order("gists.id, rank DESC")
+	if user := ctx.Query("user"); user != "" {
+		matches = matches.Where("gists.username = ?", user)
+	}
+	if language := ctx.Query("language"); language != "" {
+		matches = matches.Where("LOWER(gist_contents.language) = LOWER(?)", language)
+	}
+	matches = newGistPolicy(ctx, sc.DB).visibleScope(matches)
+
+	var rows []gistSearchRow
+	result := sc.DB.Table("(?) AS matches", matches).
+		Select(`gists.id, gists.username, gists.name, gists.title, gists.private, gists.star_count, gists.updated_at,
+			gist_contents.filename, gist_contents.language, matches.rank, COUNT(*) OVER () AS total_count,
+			ts_headline('simple', gists.title, `+searchQuery+`, ?) AS title_highlight,
+			ts_headline('simple', left(gist_contents.content, ?), `+searchQuery+`, ?) AS snippet`,
+			query, titleHighlightOptions, searchedContentLength, query, snippetOptions).
+		Joins("JOIN gists ON gists.id = matches.gist_id").
+		Joins("JOIN gist_contents ON gist_contents.id = matches.file_id").
+		Order("matches.rank DESC, matches.gist_id").
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	searchResults := models.GistSearchResults{Results: make([]models.GistSearchResult, 0, len(rows))}
+	for _, row := range rows {
+		searchResults.TotalCount = row.TotalCount
+		searchResults.Results = append(searchResults.Results, models.GistSearchResult{
+			ID:             row.ID,
+			Username:       row.Username,
+			Name:           row.Name,
+			Title:          row.Title,
+			TitleHighlight: renderHighlight(row.TitleHighlight),
+			Private:        row.Private,
+			StarCount:      row.StarCount,
+			UpdatedAt:      row.UpdatedAt,
+			Filename:       row.Filename,
+			Language:       row.Language,
+			Snippet:        renderHighlight(row.Snippet),
+			Rank:           row.Rank,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, models.GistSearchResultsWrapper{
+		Results: searchResults,
+		Links:   searchPageLinks(ctx, offset, limit, searchResults.TotalCount),
+	})
+}
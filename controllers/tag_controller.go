@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type TagController struct {
+	DB *gorm.DB
+}
+
+func NewTagController(DB *gorm.DB) TagController {
+	return TagController{
+		DB: DB,
+	}
+}
+
+const (
+	maxGistTags  = 20
+	maxTagLength = 50
+)
+
+var (
+	tagSeparators = regexp.MustCompile(`[\s_]+`)
+	validTag      = regexp.MustCompile(`^[a-z0-9][a-z0-9+#.-]*$`)
+)
+
+// normalizeTag lowercases a tag and replaces spaces and underscores with hyphens, e.g. "Shell Script" becomes
+// "shell-script". Tags must then start with a letter or a digit and only contain letters, digits and -+#. (e.g. c++).
+func normalizeTag(tag string) (string, error) {
+	normalizedTag := strings.Trim(tagSeparators.ReplaceAllString(strings.ToLower(strings.TrimSpace(tag)), "-"), "-")
+	if len(normalizedTag) > maxTagLength {
+		return "", fmt.Errorf("tag: '%s' is longer than %d characters", tag, maxTagLength)
+	}
+	if !validTag.MatchString(normalizedTag) {
+		return "", fmt.Errorf("invalid tag: '%s', tags can only contain letters, digits and -+#.", tag)
+	}
+	return normalizedTag, nil
+}
+
+// normalizeTags normalises the requested tags of a gist and removes the duplicates, keeping the first occurrences
+func normalizeTags(tags []string) ([]string, error) {
+	normalizedTags := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		normalizedTag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[normalizedTag] {
+			seen[normalizedTag] = true
+			normalizedTags = append(normalizedTags, normalizedTag)
+		}
+	}
+
+	if len(normalizedTags) > maxGistTags {
+		return nil, fmt.Errorf("a gist can have at most %d tags", maxGistTags)
+	}
+	return normalizedTags, nil
+}
+
+// replaceGistTags sets the tags of a gist, the previous ones are removed. It must be run inside a transaction.
+func replaceGistTags(tx *gorm.DB, gistId uuid.UUID, tags []string) error {
+	result := tx.Where("gist_id = ?", gistId).Delete(&models.GistTag{})
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return result.Error
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+	gistTags := make([]models.GistTag, 0, len(tags))
+	for _, tag := range tags {
+		gistTags = append(gistTags, models.GistTag{GistID: gistId, Tag: tag})
+	}
+	result = tx.Create(&gistTags)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return result.Error
+	}
+	return nil
+}
+
+// withGistTags fills the tags of the gists in place, sorted alphabetically
+func withGistTags(db *gorm.DB, gists []models.GistWithoutComments) {
+	ids := make([]uuid.UUID, 0, len(gists))
+	for i := range gists {
+		ids = append(ids, gists[i].ID)
+		gists[i].Tags = make([]string, 0)
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	var gistTags []models.GistTag
+	result := db.Where("gist_id IN ?", ids).Order("tag").Find(&gistTags)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		return
+	}
+
+	tagsByGist := make(map[uuid.UUID][]string)
+	for _, gistTag := range gistTags {
+		tagsByGist[gistTag.GistID] = append(tagsByGist[gistTag.GistID], gistTag.Tag)
+	}
+	for i := range gists {
+		if tags, exists := tagsByGist[gists[i].ID]; exists {
+			gists[i].Tags = tags
+		}
+	}
+}
+
+//	@Summary		Get the tags starting with a prefix, the most used tags first
+//	@Description	The usage count of a tag is the number of gists visible to the current user having it.
+//	@Tags			Tags
+//	@Produce		json
+//	@Param			prefix	query		string	false	"The beginning of the tags, all tags are returned when empty"
+//	@Param			limit	query		int		false	"The number of tags to return, between 1 and 100, defaults to 30"
+//	@Success		200		{object}	models.TagCountArrayWrapper
+//	@Failure		400		{object}	models.ErrorResponseWrapper
+//	@Failure		500		{object}	models.ErrorResponseWrapper
+//	@Router			/tags [get]
+func (tc *TagController) GetTags(ctx *gin.Context) {
+	limit, ok := parsePageLimit(ctx)
+	if !ok {
+		return
+	}
+
+	query := tc.DB.Model(&models.GistTag{}).
+		Select("gist_tags.tag, COUNT(*) AS count").
+		Joins("JOIN gists ON gists.id = gist_tags.gist_id").
+		Where("gists.deleted_at IS NULL").
+		Group("gist_tags.tag").
+		Order("count DESC, gist_tags.tag").
+		Limit(limit)
+	if prefix := strings.ToLower(strings.TrimSpace(ctx.Query("prefix"))); prefix != "" {
+		query = query.Where("gist_tags.tag LIKE ?", likeEscaper.Replace(prefix)+"%")
+	}
+
+	tags := make([]models.TagCount, 0)
+	result := newGistPolicy(ctx, tc.DB).visibleScope(query).Scan(&tags)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.TagCountArrayWrapper{Tags: tags})
+}
+
+//	@Summary	Get the gists with a tag visible to the current user, DOES NOT load the gist comments
+//	@Tags		Tags
+//	@Produce	json
+//	@Param		tag				path		string	true	"The tag, it is normalised like the tags of gists"
+//	@Param		sort			query		string	false	"created (default), updated or stars"
+//	@Param		direction		query		string	false	"asc or desc (default)"
+//	@Param		limit			query		int		false	"The number of gists to return, between 1 and 100, defaults to 30"
+//	@Param		cursor			query		string	false	"The cursor of the next or previous page, taken from the links"
+//	@Param		If-None-Match	header		string	false	"The ETag of the cached response"
+//	@Success	200				{object}	models.GistWithoutCommentsPageWrapper
+//	@Success	304				"The cached response is still current"
+//	@Failure	400				{object}	models.ErrorResponseWrapper
+//	@Failure	500				{object}	models.ErrorResponseWrapper
+//	@Router		/tags/{tag}/gists [get]
+func (tc *TagController) GetTagGists(ctx *gin.Context) {
+	tag, err := normalizeTag(ctx.Params.ByName("tag"))
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, ok := parsePageRequest(ctx, gistSorts, "created", sortDescending, "gists.id")
+	if !ok {
+		return
+	}
+
+	var taggedGists []models.Gist
+	taggedGistIds := tc.DB.Model(&models.GistTag{}).Select("gist_id").Where("tag = ?", tag)
+	query := tc.DB.Preload("Files", orderedFiles).Where("gists.id IN (?)", taggedGistIds)
+	result := page.apply(newGistPolicy(ctx, tc.DB).visibleScope(query)).Find(&taggedGists)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	taggedGists, links := paginate(ctx, page, taggedGists, gistSortValue(page))
+
+	gists := make([]models.GistWithoutComments, 0, len(taggedGists))
+	for _, gist := range taggedGists {
+		gists = append(gists, toGistWithoutComments(gist))
+	}
+	withGistDetails(tc.DB, gists)
+
+	// No Last-Modified, removing a tag from a gist does not change the modification time of the remaining ones
+	utils.SetCacheControl(ctx, isPublicResponse(ctx))
+	if utils.NotModified(ctx, utils.NewETag(gistsETag(gists), links.Next, links.Prev), time.Time{}) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.GistWithoutCommentsPageWrapper{Gists: gists, Links: links})
+}
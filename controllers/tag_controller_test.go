@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected string
+		valid    bool
+	}{
+		{tag: "go", expected: "go", valid: true},
+		{tag: "Shell Script", expected: "shell-script", valid: true},
+		{tag: "  snake_case  ", expected: "snake-case", valid: true},
+		{tag: "a _ b", expected: "a-b", valid: true},
+		{tag: "_leading", expected: "leading", valid: true},
+		{tag: "c++", expected: "c++", valid: true},
+		{tag: "C#", expected: "c#", valid: true},
+		{tag: "node.js", expected: "node.js", valid: true},
+		{tag: "3d", expected: "3d", valid: true},
+		{tag: strings.Repeat("a", maxTagLength), expected: strings.Repeat("a", maxTagLength), valid: true},
+		{tag: strings.Repeat("a", maxTagLength+1)},
+		{tag: ""},
+		{tag: "   "},
+		{tag: ".net"},
+		{tag: "+1"},
+		{tag: "tag!"},
+		{tag: "étiquette"},
+	}
+
+	for _, test := range tests {
+		normalizedTag, err := normalizeTag(test.tag)
+		if test.valid != (err == nil) {
+			t.Errorf("%q: expected valid to be %t, got error %v", test.tag, test.valid, err)
+			continue
+		}
+		if normalizedTag != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.tag, test.expected, normalizedTag)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := normalizeTags([]string{"Go", "web_dev", "go", "Web Dev", "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(tags, ",") != "go,web-dev,api" {
+		t.Errorf("expected the first occurrences of the tags, got %v", tags)
+	}
+
+	tags, err = normalizeTags(nil)
+	if err != nil || len(tags) != 0 {
+		t.Errorf("expected no tags, got %v (%v)", tags, err)
+	}
+
+	_, err = normalizeTags([]string{"go", "not valid!"})
+	if err == nil {
+		t.Error("expected an invalid tag to be rejected")
+	}
+
+	tooManyTags := make([]string, 0, maxGistTags+1)
+	for i := 0; i <= maxGistTags; i++ {
+		tooManyTags = append(tooManyTags, "tag"+strconv.Itoa(i))
+	}
+	_, err = normalizeTags(tooManyTags)
+	if err == nil {
+		t.Errorf("expected more than %d tags to be rejected", maxGistTags)
+	}
+
+	// Duplicates do not count towards the limit
+	duplicatedTags := make([]string, 0, maxGistTags+1)
+	for i := 0; i <= maxGistTags; i++ {
+		duplicatedTags = append(duplicatedTags, "go")
+	}
+	tags, err = normalizeTags(duplicatedTags)
+	if err != nil || len(tags) != 1 {
+		t.Errorf("expected a single tag, got %v (%v)", tags, err)
+	}
+}
@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
@@ -10,6 +12,7 @@ import (
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserController struct {
@@ -60,9 +63,12 @@ func (uc *UserController) GetMe(ctx *gin.Context) {
 //	@Summary	Get the publicly visible details of a user, DOES NOT load gists
 //	@Tags		User Operations
 //	@Produce	json
-//	@Param		username	path		string	true	"The username to get"
-//	@Success	200			{object}	models.PublicUserProfileResponseWrapper
-//	@Failure	404			{object}	models.ErrorResponseWrapper
+//	@Param		username			path		string	true	"The username to get"
+//	@Param		If-None-Match		header		string	false	"The ETag of the cached response"
+//	@Param		If-Modified-Since	header		string	false	"The Last-Modified date of the cached response"
+//	@Success	200					{object}	models.PublicUserProfileResponseWrapper
+//	@Success	304					"The cached response is still current"
+//	@Failure	404					{object}	models.ErrorResponseWrapper
 //	@Router		/users/{username} [get]
 func (uc *UserController) GetUser(ctx *gin.Context) {
 	username := ctx.Params.ByName("username")
@@ -81,6 +87,20 @@ func (uc *UserController) GetUser(ctx *gin.Context) {
 		}
 	}
 
+	lastModified := user.UpdatedAt
+	if user.UserMetadata.UpdatedAt.After(lastModified) {
+		lastModified = user.UserMetadata.UpdatedAt
+	}
+	etag := utils.NewETag(
+		user.Username,
+		strconv.FormatInt(user.UpdatedAt.UnixMicro(), 10),
+		strconv.FormatInt(user.UserMetadata.UpdatedAt.UnixMicro(), 10),
+	)
+	utils.SetCacheControl(ctx, true)
+	if utils.NotModified(ctx, etag, lastModified) {
+		return
+	}
+
 	publicUserProfile := models.PublicUserProfileResponse{
 		Username:     user.Username,
 		FirstName:    user.FirstName,
@@ -97,47 +117,63 @@ func (uc *UserController) GetUser(ctx *gin.Context) {
 	})
 }
 
-//	@Summary	Get the publicly visible gists of a user, DOES NOT load the gist comments
+//	@Summary	Get the gists of a user visible to the current user, DOES NOT load the gist comments
 //	@Tags		User Operations
 //	@Produce	json
-//	@Param		username	path		string	true	"The username to get gists for"
-//	@Success	200			{object}	models.GistWithoutCommentsArrayWrapper
-//	@Failure	404			{object}	models.ErrorResponseWrapper
+//	@Param		username			path		string	true	"The username to get gists for"
+//	@Param		sort				query		string	false	"created (default), updated or stars"
+//	@Param		direction			query		string	false	"asc or desc (default)"
+//	@Param		limit				query		int		false	"The number of gists to return, between 1 and 100, defaults to 30"
+//	@Param		cursor				query		string	false	"The cursor of the next or previous page, taken from the links"
+//	@Param		If-None-Match		header		string	false	"The ETag of the cached response"
+//	@Param		If-Modified-Since	header		string	false	"The Last-Modified date of the cached response"
+//	@Success	200					{object}	models.GistWithoutCommentsPageWrapper
+//	@Success	304					"The cached response is still current"
+//	@Failure	400					{object}	models.ErrorResponseWrapper
+//	@Failure	404					{object}	models.ErrorResponseWrapper
+//	@Failure	500					{object}	models.ErrorResponseWrapper
 //	@Router		/users/{username}/gists [get]
 func (uc *UserController) GetUserGists(ctx *gin.Context) {
 	username := ctx.Params.ByName("username")
 
+	page, ok := parsePageRequest(ctx, gistSorts, "created", sortDescending, "gists.id")
+	if !ok {
+		return
+	}
+
 	var user models.User
-	result := uc.DB.
-		Preload("Gists").
-		Preload("Gists.GistContent").
-		First(&user, "username = ?", username)
+	result := uc.DB.First(&user, "username = ?", username)
 	if result.Error != nil {
 		utils.NewErrorResponse(ctx, http.StatusNotFound, "user with username: '"+username+"' does not exist")
 		return
 	}
 
-	gists := make([]models.GistWithoutComments, 0)
-	for _, gist := range user.Gists {
-		if !gist.Private {
-			gists = append(gists, models.GistWithoutComments{
-				Username:    gist.Username,
-				StarCount:   gist.StarCount,
-				ID:          gist.ID,
-				Private:     gist.Private,
-				GistContent: gist.GistContent,
-				Name:        gist.Name,
-				Title:       gist.Title,
-				CreatedAt:   gist.CreatedAt,
-				UpdatedAt:   gist.UpdatedAt,
-			})
-		}
+	var userGists []models.Gist
+	query := uc.DB.Preload("Files", orderedFiles).Where("gists.username = ?", user.Username)
+	result = page.apply(newGistPolicy(ctx, uc.DB).visibleScope(query)).Find(&userGists)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	userGists, links := paginate(ctx, page, userGists, gistSortValue(page))
+
+	gists := make([]models.GistWithoutComments, 0, len(userGists))
+	for _, gist := range userGists {
+		gists = append(gists, toGistWithoutComments(gist))
 	}
+	withGistDetails(uc.DB, gists)
 
-	ctx.JSON(http.StatusOK, models.GistWithoutCommentsArrayWrapper{Gists: gists})
+	utils.SetCacheControl(ctx, isPublicResponse(ctx))
+	if utils.NotModified(ctx, utils.NewETag(gistsETag(gists), links.Next, links.Prev), lastGistActivity(userGists)) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.GistWithoutCommentsPageWrapper{Gists: gists, Links: links})
 }
 
-//	@Summary	Get the publicly visible gist Ids of a user
+//	@Summary	Get the gist Ids of a user visible to the current user
 //	@Tags		User Operations
 //	@Produce	json
 //	@Param		username	path		string	true	"The username to get gists for"
@@ -148,10 +184,8 @@ func (uc *UserController) GetUserGistsIds(ctx *gin.Context) {
 	username := ctx.Params.ByName("username")
 
 	var user models.User
-
-	// Gist is not private
 	result := uc.DB.
-		Preload("Gists", "private = ?", false).
+		Preload("Gists").
 		First(&user, "username = ?", username)
 	if result.Error != nil {
 		utils.NewErrorResponse(ctx, http.StatusNotFound, "user with username: '"+username+"' does not exist")
@@ -159,7 +193,7 @@ func (uc *UserController) GetUserGistsIds(ctx *gin.Context) {
 	}
 
 	gistIds := make([]uuid.UUID, 0)
-	for _, gist := range user.Gists {
+	for _, gist := range newGistPolicy(ctx, uc.DB).filterVisible(user.Gists) {
 		gistIds = append(gistIds, gist.ID)
 	}
 
@@ -199,37 +233,61 @@ func (uc *UserController) CreateGist(ctx *gin.Context) {
 		}
 	}
 
-	// TODO: There is some problem with content, check that
+	files := make([]models.GistContent, 0, len(payload.Files))
+	filenames := make(map[string]bool)
+	for i, file := range payload.Files {
+		if filenames[file.Filename] {
+			utils.NewErrorResponse(ctx, http.StatusBadRequest, "File with name: '"+file.Filename+"' is repeated")
+			return
+		}
+		filenames[file.Filename] = true
+		files = append(files, newGistFile(file.Filename, file.Content, i))
+	}
+
+	tags, err := normalizeTags(payload.Tags)
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	newGist := models.Gist{
-		Username: currentUser.Username,
-		Private:  payload.Private,
-		GistContent: models.GistContent{
-			Content: payload.Content,
-		},
-		Name:      payload.Name,
-		Title:     payload.Title,
-		CreatedAt: now,
-		UpdatedAt: now,
+		Username:  currentUser.Username,
+		Private:   payload.Private,
+		Files:     files,
+		Name:           payload.Name,
+		Title:          payload.Title,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		LastActivityAt: now,
 	}
 
-	result := uc.DB.Session(&gorm.Session{FullSaveAssociations: true}).Create(&newGist)
-	if result.Error != nil {
-		utils.NewErrorResponse(ctx, http.StatusBadRequest, result.Error.Error())
+	err = uc.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Session(&gorm.Session{FullSaveAssociations: true}).Create(&newGist)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			return result.Error
+		}
+
+		err := replaceGistTags(tx, newGist.ID, tags)
+		if err != nil {
+			return err
+		}
+
+		_, err = recordGistRevision(tx, newGist, currentUser.Username, now)
+		if err != nil {
+			zap.L().Error(err.Error())
+			return err
+		}
+
+		return recordActivity(tx, currentUser.Username, activityGistCreated, newGist.ID, nil, now)
+	})
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, models.GistWithoutCommentsWrapper{
-		Gist: models.GistWithoutComments{
-			Username:    newGist.Username,
-			StarCount:   newGist.StarCount,
-			ID:          newGist.ID,
-			Private:     newGist.Private,
-			GistContent: newGist.GistContent,
-			Name:        newGist.Name,
-			Title:       newGist.Title,
-			CreatedAt:   newGist.CreatedAt,
-			UpdatedAt:   newGist.UpdatedAt,
-		},
+		Gist: gistWithDetails(uc.DB, newGist),
 	})
 }
 
@@ -242,6 +300,7 @@ func (uc *UserController) CreateGist(ctx *gin.Context) {
 //	@Failure	400					{object}	models.ErrorResponseWrapper
 //	@Failure	401					{object}	models.ErrorResponseWrapper
 //	@Failure	403					{object}	models.ErrorResponseWrapper
+//	@Failure	404					{object}	models.ErrorResponseWrapper
 //	@Router		/users/comments [post]
 func (uc *UserController) CreateCommentOnGist(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(models.User)
@@ -254,29 +313,81 @@ func (uc *UserController) CreateCommentOnGist(ctx *gin.Context) {
 
 	now := time.Now()
 
-	gistUUID, err := uuid.Parse(payload.GistId)
-	if err != nil {
-		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	gist, ok := newGistPolicy(ctx, uc.DB).loadVisibleGist(ctx, uc.DB.Preload("Files", orderedFiles), payload.GistId)
+	if !ok {
 		return
 	}
 
 	newComment := models.Comment{
-		GistID:    gistUUID,
+		GistID:    gist.ID,
 		Username:  currentUser.Username,
 		Content:   payload.Content,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
-	result := uc.DB.Create(&newComment)
-	if result.Error != nil {
-		utils.NewErrorResponse(ctx, http.StatusBadRequest, result.Error.Error())
+
+	if payload.ParentId != "" {
+		parentId, err := uuid.Parse(payload.ParentId)
+		if err != nil {
+			utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid parent comment id")
+			return
+		}
+
+		var parent models.Comment
+		result := uc.DB.First(&parent, "comment_id = ? AND gist_id = ?", parentId, gist.ID)
+		if result.Error != nil {
+			utils.NewErrorResponse(ctx, http.StatusBadRequest, "parent comment does not exist on this gist")
+			return
+		}
+		newComment.ParentID = &parent.CommentID
+	}
+
+	if payload.Filename != "" {
+		if newComment.ParentID != nil {
+			utils.NewErrorResponse(ctx, http.StatusBadRequest, "replies cannot be anchored to lines")
+			return
+		}
+
+		err := anchorLineComment(uc.DB, &newComment, gist, payload)
+		if err != nil {
+			utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	err := uc.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Create(&newComment)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		mentioned, err := syncCommentMentions(tx, gist, newComment)
+		if err != nil {
+			return err
+		}
+
+		err = touchGist(tx, gist.ID)
+		if err != nil {
+			return err
+		}
+
+		return notifyCommentRecipients(tx, gist, newComment, mentioned)
+	})
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
+
+	depth, err := commentDepth(uc.DB, newComment)
+	if err != nil {
+		zap.L().Error(err.Error())
+	}
+	newComment.Depth = depth
+	newComment.Reactions = emptyReactionCounts()
+	gistEvents.publish(gist.ID, gistEventCommentCreated, newComment)
 	ctx.JSON(http.StatusCreated, models.CommentWrapper{Comment: newComment})
 }
 
-// TODO: Fork a gist, remember to check if name is already taken
-
 //
 // ------------- UPDATE FUNCTIONS -----------------------
 //
@@ -335,11 +446,15 @@ func (uc *UserController) UpdateUserDetails(ctx *gin.Context) {
 //	@Accept		json
 //	@Produce	json
 //	@Param		UpdateGistInput	body		models.UpdateGistRequest	true	"The Input for updating user gist"
+//	@Param		If-Match		header		string						false	"The ETag of the version of the gist the update is based on"
 //	@Success	200				{object}	models.GistWithoutCommentsWrapper
+//	@Header		200				{string}	ETag	"The version of the updated gist"
 //	@Failure	400				{object}	models.ErrorResponseWrapper
 //	@Failure	401				{object}	models.ErrorResponseWrapper
 //	@Failure	403				{object}	models.ErrorResponseWrapper
 //	@Failure	404				{object}	models.ErrorResponseWrapper
+//	@Failure	409				{object}	models.ErrorResponseWrapper
+//	@Failure	412				{object}	models.GistWithoutCommentsWrapper	"The gist changed since the If-Match version, the current version is returned"
 //	@Router		/users/gists [patch]
 func (uc *UserController) UpdateGist(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(models.User)
@@ -358,7 +473,7 @@ func (uc *UserController) UpdateGist(ctx *gin.Context) {
 
 	var gist models.Gist
 	result := uc.DB.
-		Preload("GistContent").
+		Preload("Files", orderedFiles).
 		First(&gist, "id = ?", gistIdParsed)
 	if result.Error != nil {
 		utils.NewErrorResponse(ctx, http.StatusNotFound, "gist does not exist")
@@ -370,6 +485,12 @@ func (uc *UserController) UpdateGist(ctx *gin.Context) {
 		return
 	}
 
+	ifMatch := ctx.GetHeader("If-Match")
+	if ifMatch != "" && !utils.IfMatchVersion(ifMatch, gistVersion(gist.ID, gist.UpdatedAt)) {
+		respondGistModified(ctx, uc.DB, gist)
+		return
+	}
+
 	if payload.Name != "" {
 		currentUserGists := currentUser.Gists
 		for _, currentUserGist := range currentUserGists {
@@ -378,35 +499,95 @@ func (uc *UserController) UpdateGist(ctx *gin.Context) {
 				return
 			}
 		}
-		gist.Name = payload.Name
 	}
-	if payload.Title != "" {
-		gist.Title = payload.Title
-	}
-	if payload.Content != "" {
-		gist.GistContent.Content = payload.Content
+
+	var tags []string
+	if payload.Tags != nil {
+		tags, err = normalizeTags(*payload.Tags)
+		if err != nil {
+			utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
-	gist.Private = payload.Private
-	gist.UpdatedAt = time.Now()
 
-	result = uc.DB.Session(&gorm.Session{FullSaveAssociations: true}).Save(&gist)
-	if result.Error != nil {
-		utils.NewErrorResponse(ctx, http.StatusBadRequest, result.Error.Error())
+	err = uc.DB.Transaction(func(tx *gorm.DB) error {
+		// The lock keeps other updates and starting live sessions out until commit
+		var currentGist models.Gist
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "updated_at").
+			First(&currentGist, "id = ?", gistIdParsed)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// The live session would overwrite the update with its own content when saving
+		if editSessions.isActive(currentGist.ID) {
+			return errGistBeingEdited
+		}
+
+		// Another update could have been saved since the gist was loaded
+		if ifMatch != "" && !utils.IfMatchVersion(ifMatch, gistVersion(currentGist.ID, currentGist.UpdatedAt)) {
+			return errGistModified
+		}
+
+		// The changes are applied to the files as they are now that no other update can be saved
+		gist = models.Gist{}
+		result = tx.Preload("Files", orderedFiles).First(&gist, "id = ?", gistIdParsed)
+		if result.Error != nil {
+			return result.Error
+		}
+		// The gist as it was before the update, legacy gists get it recorded as their first revision
+		previousGist := gist
+
+		if payload.Name != "" {
+			gist.Name = payload.Name
+		}
+		if payload.Title != "" {
+			gist.Title = payload.Title
+		}
+		gist.Private = payload.Private
+		gist.UpdatedAt = time.Now()
+		gist.LastActivityAt = gist.UpdatedAt
+
+		files, deletedFiles, err := applyFileChanges(gist.Files, payload.Files)
+		if err != nil {
+			return err
+		}
+		gist.Files = files
+
+		if payload.Tags != nil {
+			err := replaceGistTags(tx, gist.ID, tags)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = saveGistUpdate(tx, previousGist, &gist, deletedFiles, currentUser.Username)
+		return err
+	})
+	if errors.Is(err, errGistModified) {
+		var currentGist models.Gist
+		result := uc.DB.Preload("Files", orderedFiles).First(&currentGist, "id = ?", gist.ID)
+		if result.Error != nil {
+			utils.NewErrorResponse(ctx, http.StatusNotFound, "gist does not exist")
+			return
+		}
+		respondGistModified(ctx, uc.DB, currentGist)
+		return
+	}
+	if errors.Is(err, errGistBeingEdited) {
+		utils.NewErrorResponse(ctx, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	updatedGist := gistWithDetails(uc.DB, gist)
+	ctx.Header("ETag", gistETag(updatedGist))
 	ctx.JSON(http.StatusOK, models.GistWithoutCommentsWrapper{
-		Gist: models.GistWithoutComments{
-			Username:    gist.Username,
-			StarCount:   gist.StarCount,
-			ID:          gist.ID,
-			Private:     gist.Private,
-			GistContent: gist.GistContent,
-			Name:        gist.Name,
-			Title:       gist.Title,
-			CreatedAt:   gist.CreatedAt,
-			UpdatedAt:   gist.UpdatedAt,
-		},
+		Gist: updatedGist,
 	})
 }
 
@@ -467,7 +648,12 @@ func (uc *UserController) FollowUser(ctx *gin.Context) {
 			return result.Error
 		}
 
-		return nil
+		return notify(tx, []models.Notification{{
+			Username:  userToBeFollowed.Username,
+			Actor:     currentUser.Username,
+			Type:      notificationFollow,
+			CreatedAt: time.Now(),
+		}})
 	})
 
 	if err != nil {
@@ -566,21 +752,13 @@ func (uc *UserController) StarGist(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(models.User)
 	gistId := ctx.Params.ByName("gistId")
 
-	parsedGistId, err := uuid.Parse(gistId)
-	if err != nil {
-		utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid gist id")
-		return
-	}
-
-	var gist models.Gist
-	result := uc.DB.First(&gist, "id = ?", parsedGistId)
-	if result.Error != nil {
-		utils.NewErrorResponse(ctx, http.StatusNotFound, "gist does not exist")
+	gist, ok := newGistPolicy(ctx, uc.DB).loadVisibleGist(ctx, uc.DB, gistId)
+	if !ok {
 		return
 	}
 
 	// Perform transaction to update both users
-	err = uc.DB.Transaction(func(tx *gorm.DB) error {
+	err := uc.DB.Transaction(func(tx *gorm.DB) error {
 		// Update current user
 		currentUserMetadata := currentUser.UserMetadata
 		currentUserMetadata.StarredGistsCount += 1
@@ -590,10 +768,14 @@ func (uc *UserController) StarGist(ctx *gin.Context) {
 			return result.Error
 		}
 
-		// Update gist
+		// Update gist, the star count is not part of the content so only last_activity_at changes
 		// Read https://www.postgresql.org/docs/9.1/arrays.html#ARRAYS-INPUT, well just 'cause you should know it
-		gist.StarCount += 1
-		result = tx.Save(&gist)
+		result = tx.Model(&gist).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "star_count"}}}).
+			UpdateColumns(map[string]interface{}{
+				"star_count":       gorm.Expr("star_count + 1"),
+				"last_activity_at": time.Now(),
+			})
 		if result.Error != nil {
 			zap.L().Error(result.Error.Error())
 			return result.Error
@@ -610,7 +792,21 @@ func (uc *UserController) StarGist(ctx *gin.Context) {
 			return result.Error
 		}
 
-		return nil
+		err := recordActivity(tx, currentUser.Username, activityStar, gist.ID, nil, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if gist.Username == currentUser.Username {
+			return nil
+		}
+		return notify(tx, []models.Notification{{
+			Username:  gist.Username,
+			Actor:     currentUser.Username,
+			Type:      notificationStar,
+			GistID:    &gist.ID,
+			CreatedAt: time.Now(),
+		}})
 	})
 
 	if err != nil {
@@ -618,6 +814,7 @@ func (uc *UserController) StarGist(ctx *gin.Context) {
 		return
 	}
 
+	publishStarCount(gist)
 	utils.NewSuccessResponse(ctx, http.StatusOK, "successfully starred gist")
 }
 
@@ -648,36 +845,43 @@ func (uc *UserController) UnstarGist(ctx *gin.Context) {
 	}
 
 	// Perform transaction to update both users
+	unstarred := false
 	err = uc.DB.Transaction(func(tx *gorm.DB) error {
-		// Update current user
-		currentUserMetadata := currentUser.UserMetadata
-		currentUserMetadata.StarredGistsCount -= 1
-		if currentUserMetadata.StarredGistsCount < 0 {
-			currentUserMetadata.StarredGistsCount = 0
+		starToDelete := models.Star{
+			GistID:   gist.ID,
+			Username: currentUser.Username,
 		}
-		result := tx.Save(&currentUserMetadata)
+
+		// The counts are only decremented when the gist was starred, unstarring twice is a no-op
+		result := tx.Delete(&starToDelete)
 		if result.Error != nil {
 			zap.L().Error(result.Error.Error())
 			return result.Error
 		}
+		if result.RowsAffected != 1 {
+			return nil
+		}
+		unstarred = true
 
-		// Update gist
-		gist.StarCount -= 1
-		if gist.StarCount < 0 {
-			gist.StarCount = 0
+		// Update current user
+		currentUserMetadata := currentUser.UserMetadata
+		currentUserMetadata.StarredGistsCount -= 1
+		if currentUserMetadata.StarredGistsCount < 0 {
+			currentUserMetadata.StarredGistsCount = 0
 		}
-		result = tx.Save(&gist)
+		result = tx.Save(&currentUserMetadata)
 		if result.Error != nil {
 			zap.L().Error(result.Error.Error())
 			return result.Error
 		}
 
-		starToDelete := models.Star{
-			GistID:   gist.ID,
-			Username: currentUser.Username,
-		}
-
-		result = tx.Delete(&starToDelete)
+		// Update gist, the star count is not part of the content so only last_activity_at changes
+		result = tx.Model(&gist).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "star_count"}}}).
+			UpdateColumns(map[string]interface{}{
+				"star_count":       gorm.Expr("GREATEST(star_count - 1, 0)"),
+				"last_activity_at": time.Now(),
+			})
 		if result.Error != nil {
 			zap.L().Error(result.Error.Error())
 			return result.Error
@@ -691,20 +895,150 @@ func (uc *UserController) UnstarGist(ctx *gin.Context) {
 		return
 	}
 
+	if unstarred {
+		publishStarCount(gist)
+	}
 	utils.NewSuccessResponse(ctx, http.StatusOK, "successfully unstarred gist")
 }
 
+//	@Summary	Share a private gist with another user
+//	@Tags		User Operations
+//	@Produce	json
+//	@Param		gistId		path		string	true	"The ID of the gist to share"
+//	@Param		username	path		string	true	"The username of the user to share the gist with"
+//	@Success	200			{object}	models.SuccessResponseWrapper
+//	@Failure	400			{object}	models.ErrorResponseWrapper
+//	@Failure	401			{object}	models.ErrorResponseWrapper
+//	@Failure	403			{object}	models.ErrorResponseWrapper
+//	@Failure	404			{object}	models.ErrorResponseWrapper
+//	@Router		/users/gists/{gistId}/share/{username} [patch]
+func (uc *UserController) ShareGist(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	gistId := ctx.Params.ByName("gistId")
+	username := ctx.Params.ByName("username")
+
+	gist, ok := uc.loadOwnGist(ctx, currentUser, gistId)
+	if !ok {
+		return
+	}
+
+	if username == currentUser.Username {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "You cannot share a gist with yourself")
+		return
+	}
+
+	var user models.User
+	result := uc.DB.First(&user, "username = ?", username)
+	if result.Error != nil {
+		utils.NewErrorResponse(ctx, http.StatusNotFound, "user does not exist")
+		return
+	}
+
+	share := models.GistShare{
+		GistID:   gist.ID,
+		Username: user.Username,
+	}
+	result = uc.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&share)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, result.Error.Error())
+		return
+	}
+
+	utils.NewSuccessResponse(ctx, http.StatusOK, "successfully shared gist")
+}
+
+//	@Summary	Stop sharing a private gist with another user
+//	@Tags		User Operations
+//	@Produce	json
+//	@Param		gistId		path		string	true	"The ID of the gist"
+//	@Param		username	path		string	true	"The username of the user to stop sharing the gist with"
+//	@Success	200			{object}	models.SuccessResponseWrapper
+//	@Failure	400			{object}	models.ErrorResponseWrapper
+//	@Failure	401			{object}	models.ErrorResponseWrapper
+//	@Failure	403			{object}	models.ErrorResponseWrapper
+//	@Failure	404			{object}	models.ErrorResponseWrapper
+//	@Router		/users/gists/{gistId}/unshare/{username} [patch]
+func (uc *UserController) UnshareGist(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	gistId := ctx.Params.ByName("gistId")
+	username := ctx.Params.ByName("username")
+
+	gist, ok := uc.loadOwnGist(ctx, currentUser, gistId)
+	if !ok {
+		return
+	}
+
+	shareToDelete := models.GistShare{
+		GistID:   gist.ID,
+		Username: username,
+	}
+	result := uc.DB.Delete(&shareToDelete)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, result.Error.Error())
+		return
+	}
+
+	utils.NewSuccessResponse(ctx, http.StatusOK, "successfully unshared gist")
+}
+
+//	@Summary	Get the users a gist of the current user is shared with
+//	@Tags		User Operations
+//	@Produce	json
+//	@Param		gistId	path		string	true	"The ID of the gist"
+//	@Success	200		{object}	models.StringArrayWrapper
+//	@Failure	400		{object}	models.ErrorResponseWrapper
+//	@Failure	401		{object}	models.ErrorResponseWrapper
+//	@Failure	403		{object}	models.ErrorResponseWrapper
+//	@Failure	404		{object}	models.ErrorResponseWrapper
+//	@Failure	500		{object}	models.ErrorResponseWrapper
+//	@Router		/users/gists/{gistId}/shares [get]
+func (uc *UserController) GetGistShares(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	gistId := ctx.Params.ByName("gistId")
+
+	gist, ok := uc.loadOwnGist(ctx, currentUser, gistId)
+	if !ok {
+		return
+	}
+
+	var shares []models.GistShare
+	result := uc.DB.Find(&shares, "gist_id = ?", gist.ID)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	usernames := make([]string, 0, len(shares))
+	for _, share := range shares {
+		usernames = append(usernames, share.Username)
+	}
+
+	ctx.JSON(http.StatusOK, models.StringArrayWrapper{StringArray: usernames})
+}
+
 //	@Summary	Get the followers of a user
 //	@Tags		User Operations
 //	@Produce	json
 //	@Param		username	path		string	true	"The username of the user to get the followers of"
-//	@Success	200			{object}	models.StringArrayWrapper
+//	@Param		direction	query		string	false	"asc or desc (default), followers are sorted by the time they followed"
+//	@Param		limit		query		int		false	"The number of followers to return, between 1 and 100, defaults to 30"
+//	@Param		cursor		query		string	false	"The cursor of the next or previous page, taken from the links"
+//	@Success	200			{object}	models.StringPageWrapper
+//	@Failure	400			{object}	models.ErrorResponseWrapper
 //	@Failure	404			{object}	models.ErrorResponseWrapper
 //	@Failure	500			{object}	models.ErrorResponseWrapper
 //	@Router		/users/{username}/followers [get]
 func (uc *UserController) GetFollowerList(ctx *gin.Context) {
 	username := ctx.Params.ByName("username")
 
+	page, ok := parsePageRequest(ctx, followSorts, "created", sortDescending, "follows.followed_by")
+	if !ok {
+		return
+	}
+
 	var user models.User
 	result := uc.DB.First(&user, "username = ?", username)
 	if result.Error != nil {
@@ -714,32 +1048,45 @@ func (uc *UserController) GetFollowerList(ctx *gin.Context) {
 	}
 
 	var followers []models.Follow
-	result = uc.DB.Find(&followers, "username = ?", username)
+	result = page.apply(uc.DB.Where("follows.username = ?", username)).Find(&followers)
 	if result.Error != nil {
 		zap.L().Error(result.Error.Error())
 		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
 		return
 	}
 
-	var followerUsernames []string
+	followers, links := paginate(ctx, page, followers, func(follow models.Follow) (interface{}, string) {
+		return follow.CreatedAt, follow.FollowedBy
+	})
+
+	followerUsernames := make([]string, 0, len(followers))
 	for _, follower := range followers {
 		followerUsernames = append(followerUsernames, follower.FollowedBy)
 	}
 
-	ctx.JSON(http.StatusOK, models.StringArrayWrapper{StringArray: followerUsernames})
+	ctx.JSON(http.StatusOK, models.StringPageWrapper{StringArray: followerUsernames, Links: links})
 }
 
 //	@Summary	Get the list of users a user is following
 //	@Tags		User Operations
 //	@Produce	json
 //	@Param		username	path		string	true	"The username of the user to get the following of"
-//	@Success	200			{object}	models.StringArrayWrapper
+//	@Param		direction	query		string	false	"asc or desc (default), users are sorted by the time they were followed"
+//	@Param		limit		query		int		false	"The number of users to return, between 1 and 100, defaults to 30"
+//	@Param		cursor		query		string	false	"The cursor of the next or previous page, taken from the links"
+//	@Success	200			{object}	models.StringPageWrapper
+//	@Failure	400			{object}	models.ErrorResponseWrapper
 //	@Failure	404			{object}	models.ErrorResponseWrapper
 //	@Failure	500			{object}	models.ErrorResponseWrapper
 //	@Router		/users/{username}/following [get]
 func (uc *UserController) GetFollowingList(ctx *gin.Context) {
 	username := ctx.Params.ByName("username")
 
+	page, ok := parsePageRequest(ctx, followSorts, "created", sortDescending, "follows.username")
+	if !ok {
+		return
+	}
+
 	var user models.User
 	result := uc.DB.First(&user, "username = ?", username)
 	if result.Error != nil {
@@ -749,19 +1096,23 @@ func (uc *UserController) GetFollowingList(ctx *gin.Context) {
 	}
 
 	var following []models.Follow
-	result = uc.DB.Find(&following, "followed_by = ?", username)
+	result = page.apply(uc.DB.Where("follows.followed_by = ?", username)).Find(&following)
 	if result.Error != nil {
 		zap.L().Error(result.Error.Error())
 		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
 		return
 	}
 
-	var followingUsernames []string
+	following, links := paginate(ctx, page, following, func(follow models.Follow) (interface{}, string) {
+		return follow.CreatedAt, follow.Username
+	})
+
+	followingUsernames := make([]string, 0, len(following))
 	for _, followedUser := range following {
 		followingUsernames = append(followingUsernames, followedUser.Username)
 	}
 
-	ctx.JSON(http.StatusOK, models.StringArrayWrapper{StringArray: followingUsernames})
+	ctx.JSON(http.StatusOK, models.StringPageWrapper{StringArray: followingUsernames, Links: links})
 }
 
 //	@Summary	Whether a username follows another username
@@ -795,13 +1146,23 @@ func (uc *UserController) CheckIfUserFollows(ctx *gin.Context) {
 //	@Tags		User Operations
 //	@Produce	json
 //	@Param		username	path		string	true	"The username of the user to get the starred gists of"
-//	@Success	200			{object}	models.UUIDArrayWrapper
+//	@Param		sort		query		string	false	"created (the time the gist was starred, default), updated or stars"
+//	@Param		direction	query		string	false	"asc or desc (default)"
+//	@Param		limit		query		int		false	"The number of gists to return, between 1 and 100, defaults to 30"
+//	@Param		cursor		query		string	false	"The cursor of the next or previous page, taken from the links"
+//	@Success	200			{object}	models.UUIDPageWrapper
+//	@Failure	400			{object}	models.ErrorResponseWrapper
 //	@Failure	404			{object}	models.ErrorResponseWrapper
 //	@Failure	500			{object}	models.ErrorResponseWrapper
 //	@Router		/users/{username}/starredGists [get]
 func (uc *UserController) GetStarredGists(ctx *gin.Context) {
 	username := ctx.Params.ByName("username")
 
+	page, ok := parsePageRequest(ctx, starredGistSorts, "created", sortDescending, "gists.id")
+	if !ok {
+		return
+	}
+
 	var user models.User
 	result := uc.DB.First(&user, "username = ?", username)
 	if result.Error != nil {
@@ -810,20 +1171,42 @@ func (uc *UserController) GetStarredGists(ctx *gin.Context) {
 		return
 	}
 
-	var stars []models.Star
-	result = uc.DB.Find(&stars, "username = ?", username)
+	type starredGist struct {
+		ID        uuid.UUID
+		UpdatedAt time.Time
+		StarCount int
+		StarredAt time.Time
+	}
+
+	var starredGists []starredGist
+	query := uc.DB.Model(&models.Gist{}).
+		Select("gists.id, gists.updated_at, gists.star_count, stars.created_at AS starred_at").
+		Joins("JOIN stars ON stars.gist_id = gists.id").
+		Where("stars.username = ?", username)
+	result = page.apply(newGistPolicy(ctx, uc.DB).visibleScope(query)).Scan(&starredGists)
 	if result.Error != nil {
 		zap.L().Error(result.Error.Error())
 		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
 		return
 	}
 
-	var starredGistIds []uuid.UUID
-	for _, star := range stars {
-		starredGistIds = append(starredGistIds, star.GistID)
+	starredGists, links := paginate(ctx, page, starredGists, func(gist starredGist) (interface{}, string) {
+		switch page.sortName {
+		case "updated":
+			return gist.UpdatedAt, gist.ID.String()
+		case "stars":
+			return gist.StarCount, gist.ID.String()
+		default:
+			return gist.StarredAt, gist.ID.String()
+		}
+	})
+
+	starredGistIds := make([]uuid.UUID, 0, len(starredGists))
+	for _, gist := range starredGists {
+		starredGistIds = append(starredGistIds, gist.ID)
 	}
 
-	ctx.JSON(http.StatusOK, models.UUIDArrayWrapper{UUIDArray: starredGistIds})
+	ctx.JSON(http.StatusOK, models.UUIDPageWrapper{UUIDArray: starredGistIds, Links: links})
 }
 
 //	@Summary	Whether a user has starred a gist
@@ -864,3 +1247,61 @@ func (uc *UserController) CheckIfGistStarred(ctx *gin.Context) {
 		},
 	})
 }
+
+//
+// ------------- DELETE FUNCTIONS -----------------------
+//
+
+//	@Summary	Move a gist to the trash, it can be restored until it is purged after the retention period
+//	@Tags		User Operations
+//	@Param		gistId	path	string	true	"The ID of the gist to delete"
+//	@Success	204
+//	@Failure	400	{object}	models.ErrorResponseWrapper
+//	@Failure	401	{object}	models.ErrorResponseWrapper
+//	@Failure	403	{object}	models.ErrorResponseWrapper
+//	@Failure	404	{object}	models.ErrorResponseWrapper
+//	@Failure	500	{object}	models.ErrorResponseWrapper
+//	@Router		/users/gists/{gistId} [delete]
+func (uc *UserController) DeleteGist(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	gistId := ctx.Params.ByName("gistId")
+
+	gist, ok := uc.loadOwnGist(ctx, currentUser, gistId)
+	if !ok {
+		return
+	}
+
+	result := uc.DB.Delete(&gist)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// loadOwnGist loads a gist of the current user, an error response is written and false is returned if the gist does
+// not exist or belongs to someone else
+func (uc *UserController) loadOwnGist(ctx *gin.Context, currentUser models.User, gistId string) (models.Gist, bool) {
+	var gist models.Gist
+
+	parsedGistId, err := uuid.Parse(gistId)
+	if err != nil {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "invalid gist id")
+		return gist, false
+	}
+
+	result := uc.DB.First(&gist, "id = ?", parsedGistId)
+	if result.Error != nil {
+		utils.NewErrorResponse(ctx, http.StatusNotFound, "gist does not exist")
+		return gist, false
+	}
+
+	if gist.Username != currentUser.Username {
+		utils.NewErrorResponse(ctx, http.StatusUnauthorized, "unauthorized")
+		return gist, false
+	}
+
+	return gist, true
+}
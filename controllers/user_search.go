@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Words of the query taken into account, the others are ignored
+const maxUserSearchTerms = 5
+
+// userSearchCondition matches the users having a name starting with term, or a word of their location or tagline
+// starting with it
+const userSearchCondition = `(users.username ILIKE @prefix OR users.first_name ILIKE @prefix OR users.last_name ILIKE @prefix
+	OR ' ' || user_metadata.location ILIKE @word OR ' ' || user_metadata.tagline ILIKE @word)`
+
+type userSearchRow struct {
+	Username  string
+	FirstName string
+	LastName  *string
+	Verified  bool
+	Followers int
+}
+
+//	@Summary		Search users by name, location or tagline, the most followed users first
+//	@Description	Every word of the query must be the beginning of the username, the first name, the last name or a
+//	@Description	word of the location or the tagline of the user, so that partially typed queries match.
+//	@Tags			Search
+//	@Produce		json
+//	@Param			q			query		string	true	"The search query"
+//	@Param			limit		query		int		false	"The number of users to return, between 1 and 100, defaults to 30"
+//	@Param			cursor		query		string	false	"The cursor of the next or previous page, taken from the links"
+//	@Success		200			{object}	models.PublicUserProfilePageWrapper
+//	@Failure		400			{object}	models.ErrorResponseWrapper
+//	@Failure		500			{object}	models.ErrorResponseWrapper
+//	@Router			/search/users [get]
+func (sc *SearchController) SearchUsers(ctx *gin.Context) {
+	terms := strings.Fields(ctx.Query("q"))
+	if len(terms) == 0 {
+		utils.NewErrorResponse(ctx, http.StatusBadRequest, "q is required")
+		return
+	}
+	if len(terms) > maxUserSearchTerms {
+		terms = terms[:maxUserSearchTerms]
+	}
+
+	page, ok := parsePageRequest(ctx, userSearchSorts, "followers", sortDescending, "users.username")
+	if !ok {
+		return
+	}
+
+	query := sc.DB.Table("users").
+		Select("users.username, users.first_name, users.last_name, users.verified, user_metadata.followers").
+		Joins("JOIN user_metadata ON user_metadata.username = users.username")
+	for _, term := range terms {
+		term = likeEscaper.Replace(term)
+		query = query.Where(userSearchCondition, map[string]interface{}{
+			"prefix": term + "%",
+			"word":   "% " + term + "%",
+		})
+	}
+
+	var rows []userSearchRow
+	result := page.apply(query).Scan(&rows)
+	if result.Error != nil {
+		zap.L().Error(result.Error.Error())
+		utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+	rows, links := paginate(ctx, page, rows, func(row userSearchRow) (interface{}, string) {
+		return row.Followers, row.Username
+	})
+
+	usernames := make([]string, 0, len(rows))
+	for _, row := range rows {
+		usernames = append(usernames, row.Username)
+	}
+
+	var metadata []models.UserMetadata
+	if len(usernames) != 0 {
+		result = sc.DB.Where("username IN ?", usernames).Find(&metadata)
+		if result.Error != nil {
+			zap.L().Error(result.Error.Error())
+			utils.NewErrorResponse(ctx, http.StatusInternalServerError, result.Error.Error())
+			return
+		}
+	}
+	metadataByUsername := make(map[string]models.UserMetadata, len(metadata))
+	for _, userMetadata := range metadata {
+		metadataByUsername[userMetadata.Username] = userMetadata
+	}
+
+	users := make([]models.PublicUserProfileResponse, 0, len(rows))
+	for _, row := range rows {
+		publicUserProfile := models.PublicUserProfileResponse{
+			Username:     row.Username,
+			FirstName:    row.FirstName,
+			UserMetadata: metadataByUsername[row.Username],
+			Verified:     row.Verified,
+		}
+		if row.LastName != nil {
+			publicUserProfile.LastName = *row.LastName
+		}
+		users = append(users, publicUserProfile)
+	}
+
+	ctx.JSON(http.StatusOK, models.PublicUserProfilePageWrapper{Users: users, Links: links})
+}
@@ -24,7 +24,13 @@ func ConnectDB(config *Config) {
 		config.DBPort,
 	)
 
-	var gormConfig = &gorm.Config{}
+	var gormConfig = &gorm.Config{
+		// Postgres keeps microseconds, times written are rounded the same way so that they equal the times read back
+		// later (entity tags are derived from them)
+		NowFunc: func() time.Time {
+			return time.Now().Round(time.Microsecond)
+		},
+	}
 	if config.AppEnv != "production" {
 		newLogger := logger.New(
 			log.New(os.Stdout, "\n", log.LstdFlags), // io writer
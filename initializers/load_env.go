@@ -15,6 +15,8 @@ type Config struct {
 	ServerPort     string `mapstructure:"PORT"`
 
 	ClientOrigin string `mapstructure:"CLIENT_ORIGIN"`
+	// Public URL of this API, used for links in emails which are handled by the API itself
+	ServerOrigin string `mapstructure:"SERVER_ORIGIN"`
 
 	AccessTokenPrivateKey  string        `mapstructure:"ACCESS_TOKEN_PRIVATE_KEY"`
 	AccessTokenPublicKey   string        `mapstructure:"ACCESS_TOKEN_PUBLIC_KEY"`
@@ -31,9 +33,15 @@ type Config struct {
 	SMTPPort  int    `mapstructure:"SMTP_PORT"`
 	SMTPUser  string `mapstructure:"SMTP_USER"`
 
+	// Key used to sign the unsubscribe links of notification emails
+	UnsubscribeSecret string `mapstructure:"UNSUBSCRIBE_SECRET"`
+
 	GitHubClientId     string `mapstructure:"GITHUB_CLIENT_ID"`
 	GitHubClientSecret string `mapstructure:"GITHUB_CLIENT_SECRET"`
 
+	// How long trashed gists can be restored before they are purged, defaults to 30 days
+	GistTrashRetention time.Duration `mapstructure:"GIST_TRASH_RETENTION"`
+
 	AppEnv string `mapstructure:"APP_ENV"`
 }
 
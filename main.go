@@ -28,6 +28,12 @@ var (
 
 	GistController      controllers.GistController
 	GistRouteController routes.GistRouteController
+
+	SearchController      controllers.SearchController
+	SearchRouteController routes.SearchRouteController
+
+	TagController      controllers.TagController
+	TagRouteController routes.TagRouteController
 )
 
 func init() {
@@ -54,22 +60,61 @@ func init() {
 		&models.Gist{},
 		&models.Comment{},
 		&models.GistContent{},
+		&models.GistRevision{},
+		&models.GistRevisionFile{},
 		&models.Follow{},
 		&models.Star{},
+		&models.GistShare{},
+		&models.GistReaction{},
+		&models.CommentReaction{},
+		&models.CommentMention{},
+		&models.Notification{},
+		&models.EmailPreferences{},
+		&models.ActivityEvent{},
+		&models.GistCollaborator{},
+		&models.GistTag{},
 	)
 	if err != nil {
 		zap.L().Error(err.Error())
 		return
 	}
+
+	// Before multi-file support the only file of a gist referenced it by its own ID, AutoMigrate does not drop the
+	// constraint and new files would violate it
+	if initializers.DB.Migrator().HasConstraint(&models.GistContent{}, "fk_gists_gist_content") {
+		err = initializers.DB.Migrator().DropConstraint(&models.GistContent{}, "fk_gists_gist_content")
+		if err != nil {
+			zap.L().Error(err.Error())
+			return
+		}
+	}
+
+	// Gists created before multi-file support stored their only file with the same ID as the gist
+	err = initializers.DB.Exec(`UPDATE gist_contents SET gist_id = gists.id, filename = gists.name
+		FROM gists WHERE gist_contents.gist_id IS NULL AND gist_contents.id = gists.id`).Error
+	if err != nil {
+		zap.L().Error(err.Error())
+		return
+	}
+
+	err = controllers.CreateSearchIndexes(initializers.DB)
+	if err != nil {
+		zap.L().Error(err.Error())
+		return
+	}
 	fmt.Println("Migration complete")
 
 	AuthController = controllers.NetAuthController(initializers.DB)
 	UserController = controllers.NewUserController(initializers.DB)
 	GistController = controllers.NewGistController(initializers.DB)
+	SearchController = controllers.NewSearchController(initializers.DB)
+	TagController = controllers.NewTagController(initializers.DB)
 
 	AuthRouteController = routes.NewAuthRouteController(AuthController)
 	UserRouteController = routes.NewUserRouteController(UserController)
 	GistRouteController = routes.NewGistRouteController(GistController)
+	SearchRouteController = routes.NewSearchRouteController(SearchController)
+	TagRouteController = routes.NewTagRouteController(TagController)
 
 	server = gin.Default()
 }
@@ -90,6 +135,8 @@ func main() {
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = []string{"http://localhost:" + config.ServerPort, config.ClientOrigin}
 	corsConfig.AllowCredentials = true
+	corsConfig.AddAllowHeaders("If-Match", "If-None-Match", "If-Modified-Since")
+	corsConfig.AddExposeHeaders("ETag", "Link")
 
 	server.Use(cors.New(corsConfig))
 
@@ -99,9 +146,15 @@ func main() {
 	docs.SwaggerInfo.Host = "localhost:" + config.ServerPort
 	server.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	controllers.StartTrashPurger(initializers.DB, config)
+	controllers.StartNotificationMailer(initializers.DB, config)
+	controllers.StartDigestMailer(initializers.DB, config)
+
 	AuthRouteController.AuthRoute(router)
 	UserRouteController.UserRoute(router)
 	GistRouteController.GistRoute(router)
+	SearchRouteController.SearchRoute(router)
+	TagRouteController.TagRoute(router)
 	zap.L().Fatal("running server on port: " + config.ServerPort,
 		zap.Error(server.Run(":" + config.ServerPort)))
 }
@@ -16,17 +16,7 @@ import (
 
 func DeserializeUser() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		var accessToken string
-		cookie, err := ctx.Cookie("access_token")
-
-		authorizationHeader := ctx.Request.Header.Get("Authorization")
-		fields := strings.Fields(authorizationHeader)
-
-		if len(fields) != 0 && fields[0] == "Bearer" {
-			accessToken = fields[1]
-		} else if err == nil {
-			accessToken = cookie
-		}
+		accessToken := getAccessToken(ctx)
 
 		if accessToken == "" {
 			statusCode := http.StatusUnauthorized
@@ -70,3 +60,45 @@ func DeserializeUser() gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// OptionalDeserializeUser sets the current user when the request carries a valid access token, requests without one
+// (or with an invalid one) are served anonymously instead of being rejected
+func OptionalDeserializeUser() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		accessToken := getAccessToken(ctx)
+		if accessToken == "" {
+			ctx.Next()
+			return
+		}
+
+		config, _ := initializers.LoadConfig(os.Getenv("API_ENV_CONFIG_PATH"))
+		sub, err := utils.ValidateToken(accessToken, config.AccessTokenPublicKey)
+		if err != nil {
+			ctx.Next()
+			return
+		}
+
+		var user models.User
+		result := initializers.DB.Preload(clause.Associations).First(&user, "username = ?", fmt.Sprint(sub))
+		if result.Error == nil {
+			ctx.Set("currentUser", user)
+		}
+		ctx.Next()
+	}
+}
+
+func getAccessToken(ctx *gin.Context) string {
+	var accessToken string
+	cookie, err := ctx.Cookie("access_token")
+
+	authorizationHeader := ctx.Request.Header.Get("Authorization")
+	fields := strings.Fields(authorizationHeader)
+
+	if len(fields) > 1 && fields[0] == "Bearer" {
+		accessToken = fields[1]
+	} else if err == nil {
+		accessToken = cookie
+	}
+
+	return accessToken
+}
@@ -52,16 +52,41 @@ type PublicUserProfileResponse struct {
 	Verified     bool         `json:"verified"`
 }
 
+type GistFileRequest struct {
+	Filename string `json:"filename" binding:"required"`
+	Content  string `json:"content" binding:"required"`
+}
+
 type CreateGistRequest struct {
-	Private bool   `json:"private"`
-	Content string `json:"content" binding:"required"`
-	Name    string `json:"name" binding:"required"`
-	Title   string `json:"title" binding:"required"`
+	Private bool              `json:"private"`
+	Files   []GistFileRequest `json:"files" binding:"required,min=1,dive"`
+	Name    string            `json:"name" binding:"required"`
+	Title   string            `json:"title" binding:"required"`
+
+	// Optional, normalised to lowercase with spaces and underscores replaced by hyphens
+	Tags []string `json:"tags"`
 }
 
 type CommentOnGistRequest struct {
 	Content string `json:"content" binding:"required"`
 	GistId  string `json:"gistId" binding:"required"`
+
+	// Optional, the comment to reply to
+	ParentId string `json:"parentId"`
+
+	// Optional, anchors the comment to lines of a file. EndLine defaults to StartLine and Revision to the latest one
+	Filename  string `json:"filename"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	Revision  int    `json:"revision"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+type ReactionRequest struct {
+	Content string `json:"content" binding:"required"`
 }
 
 type UpdateUserDetailsRequest struct {
@@ -73,12 +98,23 @@ type UpdateUserDetailsRequest struct {
 	Tagline        string `json:"tagline"`
 }
 
+// UpdateGistFileRequest : Filename selects the file to change, a file that does not exist yet is created
+type UpdateGistFileRequest struct {
+	Filename    string `json:"filename" binding:"required"`
+	NewFilename string `json:"newFilename"`
+	Content     string `json:"content"`
+	Delete      bool   `json:"delete"`
+}
+
 type UpdateGistRequest struct {
-	Private bool   `json:"private"`
-	Content string `json:"content"`
-	Name    string `json:"name"`
-	Title   string `json:"title"`
-	GistId  string `json:"gistId" binding:"required"`
+	Private bool                    `json:"private"`
+	Files   []UpdateGistFileRequest `json:"files" binding:"dive"`
+	Name    string                  `json:"name"`
+	Title   string                  `json:"title"`
+	GistId  string                  `json:"gistId" binding:"required"`
+
+	// Optional, replaces all the tags of the gist when set. An empty array removes them.
+	Tags *[]string `json:"tags"`
 }
 
 type ErrorResponse struct {
@@ -101,4 +137,13 @@ type AccessCodeResponse struct {
 
 type BooleanResponse struct {
 	Result bool `json:"result"`
+}
+
+type UpdateEmailPreferencesRequest struct {
+	Comments *bool `json:"comments"`
+	Stars    *bool `json:"stars"`
+	Follows  *bool `json:"follows"`
+	Mentions *bool `json:"mentions"`
+
+	Digest *string `json:"digest" binding:"omitempty,oneof=off daily weekly"`
 }
\ No newline at end of file
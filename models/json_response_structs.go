@@ -27,10 +27,13 @@ type GistWithoutComments struct {
 	Username string
 
 	StarCount int
+	ForkCount int
 
-	ID          uuid.UUID
-	Private     bool
-	GistContent GistContent
+	ForkedFrom *uuid.UUID
+
+	ID      uuid.UUID
+	Private bool
+	Files   []GistContent
 
 	// We are hard-coding in logic to make sure name is unique across all gists of a user
 	Name string
@@ -38,6 +41,11 @@ type GistWithoutComments struct {
 	Title     string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	// Number of reactions per reaction content
+	Reactions map[string]int
+
+	Tags []string
 }
 
 type GistWithoutCommentsWrapper struct {
@@ -78,4 +86,239 @@ type UserMetadataWrapper struct {
 
 type BooleanResponseWrapper struct {
 	BooleanResponse BooleanResponse `json:"data"`
-}
\ No newline at end of file
+}
+
+type GistRevisionSummary struct {
+	Revision  int
+	Author    string
+	Name      string
+	Title     string
+	CreatedAt time.Time
+}
+
+type GistRevisionSummaryArrayWrapper struct {
+	Revisions []GistRevisionSummary `json:"data"`
+}
+
+type GistRevisionWrapper struct {
+	Revision GistRevision `json:"data"`
+}
+
+// DiffLine : Type is one of "context", "add" or "delete", line numbers are 0 when the line does not exist on that side
+type DiffLine struct {
+	Type    string
+	Content string
+	OldLine int
+	NewLine int
+}
+
+type DiffHunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []DiffLine
+}
+
+// FileDiff : Status is one of "added", "deleted" or "modified"
+type FileDiff struct {
+	OldFilename string
+	NewFilename string
+	Status      string
+	Hunks       []DiffHunk
+}
+
+type GistComparison struct {
+	GistID       uuid.UUID
+	FromRevision int
+	ToRevision   int
+	Title        []DiffHunk
+	Name         []DiffHunk
+	Files        []FileDiff
+
+	// Unified is the same comparison rendered in the unified diff format
+	Unified string
+}
+
+type GistComparisonWrapper struct {
+	Comparison GistComparison `json:"data"`
+}
+
+type TrashedGist struct {
+	Gist      GistWithoutComments
+	DeletedAt time.Time
+
+	// The gist is permanently deleted after this time
+	PurgeAt time.Time
+}
+
+type TrashedGistArrayWrapper struct {
+	Gists []TrashedGist `json:"data"`
+}
+
+// LineCommentGroup : review comments anchored to the same lines along with their replies, in thread order
+type LineCommentGroup struct {
+	Filename  string
+	StartLine int
+	EndLine   int
+	Revision  int
+	Outdated  bool
+	Comments  []Comment
+}
+
+type LineCommentGroupArrayWrapper struct {
+	Groups []LineCommentGroup `json:"data"`
+}
+
+type Reaction struct {
+	Username  string
+	Content   string
+	CreatedAt time.Time
+}
+
+type ReactionArrayWrapper struct {
+	Reactions []Reaction `json:"data"`
+}
+
+type NotificationInbox struct {
+	UnreadCount   int64
+	Notifications []Notification
+}
+
+type NotificationInboxWrapper struct {
+	Inbox NotificationInbox `json:"data"`
+	Links PageLinks         `json:"links"`
+}
+
+type EmailPreferencesWrapper struct {
+	EmailPreferences EmailPreferences `json:"data"`
+}
+
+type FeedEvent struct {
+	ID        uuid.UUID
+	Actor     string
+	Type      string
+	GistID    uuid.UUID
+	GistOwner string
+	GistName  string
+	GistTitle string
+	ForkID    *uuid.UUID
+	CreatedAt time.Time
+}
+
+type FeedEventPageWrapper struct {
+	Events []FeedEvent `json:"data"`
+	Links  PageLinks   `json:"links"`
+}
+
+type StarCountEvent struct {
+	GistID    uuid.UUID
+	StarCount int
+}
+
+type DeletedCommentEvent struct {
+	CommentID uuid.UUID
+}
+
+// PageLinks : the URLs of the next and previous pages of a paginated list, empty when there is no such page
+type PageLinks struct {
+	Next string
+	Prev string
+}
+
+type GistWithoutCommentsPageWrapper struct {
+	Gists []GistWithoutComments `json:"data"`
+	Links PageLinks             `json:"links"`
+}
+
+type CommentPageWrapper struct {
+	Comments []Comment `json:"data"`
+	Links    PageLinks `json:"links"`
+}
+
+type StringPageWrapper struct {
+	StringArray []string  `json:"data"`
+	Links       PageLinks `json:"links"`
+}
+
+type UUIDPageWrapper struct {
+	UUIDArray []uuid.UUID `json:"data"`
+	Links     PageLinks   `json:"links"`
+}
+
+// GistSearchResult : TitleHighlight and Snippet are HTML escaped, the matching words are wrapped in <mark> elements.
+// The snippet is taken from the best matching file.
+type GistSearchResult struct {
+	ID             uuid.UUID
+	Username       string
+	Name           string
+	Title          string
+	TitleHighlight string
+	Private        bool
+	StarCount      int
+	UpdatedAt      time.Time
+	Filename       string
+	Language       string
+	Snippet        string
+	Rank           float64
+}
+
+type GistSearchResults struct {
+	TotalCount int64
+	Results    []GistSearchResult
+}
+
+type GistSearchResultsWrapper struct {
+	Results GistSearchResults `json:"data"`
+	Links   PageLinks         `json:"links"`
+}
+
+// CodeSearchLine : Match is false for the context lines around the matching lines
+type CodeSearchLine struct {
+	Number  int
+	Content string
+	Match   bool
+}
+
+// CodeSearchFragment is a run of consecutive lines of a file made of matching lines and their context
+type CodeSearchFragment struct {
+	Lines []CodeSearchLine
+}
+
+// CodeSearchFile : MatchCount is the number of matching lines, Fragments only contain the first ones
+type CodeSearchFile struct {
+	ID         uuid.UUID
+	Filename   string
+	Language   string
+	MatchCount int
+	Fragments  []CodeSearchFragment
+}
+
+type CodeSearchResult struct {
+	ID        uuid.UUID
+	Username  string
+	Name      string
+	Title     string
+	Private   bool
+	UpdatedAt time.Time
+	Files     []CodeSearchFile
+}
+
+type CodeSearchResultArrayWrapper struct {
+	Results []CodeSearchResult `json:"data"`
+	Links   PageLinks          `json:"links"`
+}
+
+type PublicUserProfilePageWrapper struct {
+	Users []PublicUserProfileResponse `json:"data"`
+	Links PageLinks                   `json:"links"`
+}
+
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+type TagCountArrayWrapper struct {
+	Tags []TagCount `json:"data"`
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type User struct {
@@ -45,17 +46,37 @@ type UserMetadata struct {
 	StarredGistsCount int `gorm:"not null"`
 	Followers         int `gorm:"not null"`
 	Following         int `gorm:"not null"`
+
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// EmailPreferences decides which notifications are also sent to the user by email, all emails are opt-in. Users
+// without a row receive no emails.
+type EmailPreferences struct {
+	Username string `gorm:"type:varchar(255);primary_key"` // Foreign Key
+	Comments bool   `gorm:"not null;default:false"`
+	Stars    bool   `gorm:"not null;default:false"`
+	Follows  bool   `gorm:"not null;default:false"`
+	Mentions bool   `gorm:"not null;default:false"`
+
+	// How often a digest of the activity is emailed, one of "off", "daily" or "weekly"
+	Digest       string     `gorm:"type:varchar(16);not null;default:'off'"`
+	LastDigestAt *time.Time `gorm:"default:null"`
 }
 
 type Gist struct {
 	Username string `gorm:"type:varchar(255)"` // Foreign Key
 
 	StarCount int `gorm:"not null"`
+	ForkCount int `gorm:"not null;default:0"`
 
-	ID          uuid.UUID   `gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
-	Comments    []Comment   `gorm:"foreignKey:GistID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
-	Private     bool        `gorm:"not null"`
-	GistContent GistContent `gorm:"foreignKey:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	// The gist this gist was forked from, nil for original gists
+	ForkedFrom *uuid.UUID `gorm:"type:uuid;index;default:null"`
+
+	ID       uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
+	Comments []Comment     `gorm:"foreignKey:GistID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Private  bool          `gorm:"not null"`
+	Files    []GistContent `gorm:"foreignKey:GistID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 
 	// We are hard-coding in logic to make sure name is unique across all gists of a user
 	Name string `gorm:"type:varchar(255);not null"`
@@ -63,11 +84,51 @@ type Gist struct {
 	Title     string    `gorm:"type:varchar(255);not null"`
 	CreatedAt time.Time `gorm:"not null"`
 	UpdatedAt time.Time `gorm:"not null"`
+
+	// Changes with UpdatedAt and with the stars, forks, reactions and comments which leave UpdatedAt untouched, it is
+	// the Last-Modified date of the gist and of its comments
+	LastActivityAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+
+	// Set when the gist is moved to the trash, trashed gists are excluded from all queries unless unscoped
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
+// GistContent is a single file of a gist, files are ordered by Position
 type GistContent struct {
-	ID      uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key"` // Foreign Key
-	Content string    `gorm:"type:text;size:10485760;not null"`
+	ID     uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
+	GistID uuid.UUID `gorm:"type:uuid;index"` // Foreign Key
+
+	// Filename is unique across the files of a gist
+	Filename string `gorm:"type:varchar(255)"`
+	Language string `gorm:"type:varchar(255)"`
+	Size     int    `gorm:"not null;default:0"`
+	Position int    `gorm:"not null;default:0"`
+
+	Content string `gorm:"type:text;size:10485760;not null"`
+}
+
+// GistRevision is an immutable snapshot of a gist, a new one is recorded every time the gist is created or updated
+type GistRevision struct {
+	ID       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
+	GistID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_gist_revision"` // Foreign Key
+	Revision int       `gorm:"not null;uniqueIndex:idx_gist_revision"`
+	Author   string    `gorm:"type:varchar(255);not null"`
+
+	Name  string             `gorm:"type:varchar(255);not null"`
+	Title string             `gorm:"type:varchar(255);not null"`
+	Files []GistRevisionFile `gorm:"foreignKey:RevisionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+type GistRevisionFile struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
+	RevisionID uuid.UUID `gorm:"type:uuid;not null;index"` // Foreign Key
+	Filename   string    `gorm:"type:varchar(255);not null"`
+	Language   string    `gorm:"type:varchar(255)"`
+	Size       int       `gorm:"not null"`
+	Position   int       `gorm:"not null"`
+	Content    string    `gorm:"type:text;size:10485760;not null"`
 }
 
 type Comment struct {
@@ -75,16 +136,101 @@ type Comment struct {
 	Username  string    `gorm:"type:varchar(255); not null"`
 	Content   string    `gorm:"type:text;size:10485760;not null"`
 	CommentID uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
+	Edited    bool      `gorm:"not null;default:false"`
+
+	// The comment this comment replies to, nil for top level comments
+	ParentID *uuid.UUID `gorm:"type:uuid;index;default:null"`
+	// Nesting level of the reply, computed while building the thread
+	Depth int `gorm:"-"`
+
+	// Review comments are anchored to a range of lines of a file, Filename is empty for regular comments. The anchor
+	// follows the lines when the gist is updated, Outdated is set once the lines are changed or removed.
+	Filename  string `gorm:"type:varchar(255);not null;default:''"`
+	StartLine int    `gorm:"not null;default:0"`
+	EndLine   int    `gorm:"not null;default:0"`
+	Revision  int    `gorm:"not null;default:0"`
+	Outdated  bool   `gorm:"not null;default:false"`
+
+	// Number of reactions per reaction content, computed when the comment is loaded
+	Reactions map[string]int `gorm:"-"`
+
 	CreatedAt time.Time `gorm:"not null"`
 	UpdatedAt time.Time `gorm:"not null"`
 }
 
 type Follow struct {
-	Username   string `gorm:"type:varchar(255);primary_key"`
-	FollowedBy string `gorm:"type:varchar(255);primary_key"`
+	Username   string    `gorm:"type:varchar(255);primary_key"`
+	FollowedBy string    `gorm:"type:varchar(255);primary_key"`
+	CreatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;index"`
 }
 
 type Star struct {
+	Username  string    `gorm:"type:varchar(255);primary_key"`
+	GistID    uuid.UUID `gorm:"type:uuid;primary_key"`
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;index"`
+}
+
+// GistShare gives a user read access to a private gist of someone else
+type GistShare struct {
+	GistID   uuid.UUID `gorm:"type:uuid;primary_key"`
 	Username string    `gorm:"type:varchar(255);primary_key"`
+}
+
+// GistCollaborator lets a user edit the content of a gist of someone else in a live editing session, collaborators can
+// also see the gist when it is private
+type GistCollaborator struct {
 	GistID   uuid.UUID `gorm:"type:uuid;primary_key"`
+	Username string    `gorm:"type:varchar(255);primary_key"`
+}
+
+// GistTag attaches a normalised tag (lowercase letters, digits and -+#.) to a gist
+type GistTag struct {
+	GistID uuid.UUID `gorm:"type:uuid;primary_key"`
+	Tag    string    `gorm:"type:varchar(50);primary_key;index"`
+}
+
+type GistReaction struct {
+	GistID    uuid.UUID `gorm:"type:uuid;primary_key"`
+	Username  string    `gorm:"type:varchar(255);primary_key"`
+	Content   string    `gorm:"type:varchar(32);primary_key"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+type CommentReaction struct {
+	CommentID uuid.UUID `gorm:"type:uuid;primary_key"`
+	Username  string    `gorm:"type:varchar(255);primary_key"`
+	Content   string    `gorm:"type:varchar(32);primary_key"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// CommentMention links a comment to a user mentioned in it with @username
+type CommentMention struct {
+	CommentID uuid.UUID `gorm:"type:uuid;primary_key"`
+	Username  string    `gorm:"type:varchar(255);primary_key"`
+}
+
+// Notification tells a user about something another user (the actor) did
+type Notification struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
+	Username  string     `gorm:"type:varchar(255);not null;index"` // The user receiving the notification
+	Actor     string     `gorm:"type:varchar(255);not null"`
+	Type      string     `gorm:"type:varchar(32);not null"`
+	GistID    *uuid.UUID `gorm:"type:uuid;index;default:null"`
+	CommentID *uuid.UUID `gorm:"type:uuid;index;default:null"`
+	Read      bool       `gorm:"not null;default:false"`
+	CreatedAt time.Time  `gorm:"not null;index"`
+
+	// Set until the notification has been considered for an email
+	EmailPending bool `gorm:"not null;default:false;index"`
+}
+
+// ActivityEvent is an entry of the activity log shown in the feed of the followers of the actor
+type ActivityEvent struct {
+	ID     uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
+	Actor  string     `gorm:"type:varchar(255);not null;index"`
+	Type   string     `gorm:"type:varchar(32);not null"`
+	GistID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	ForkID *uuid.UUID `gorm:"type:uuid;index;default:null"` // The new gist for fork events
+
+	CreatedAt time.Time `gorm:"not null;index"`
 }
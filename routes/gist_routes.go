@@ -2,6 +2,7 @@ package routes
 
 import (
 	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/controllers"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/middleware"
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,7 +16,25 @@ func NewGistRouteController(gistController controllers.GistController) GistRoute
 
 func (gc *GistRouteController) GistRoute(rg *gin.RouterGroup) {
 	router := rg.Group("gists")
-	router.GET("/:gistId", gc.gistController.GetGistById)
-	router.GET("/:gistId/comments", gc.gistController.GetGistComments)
-	router.GET("/:gistId/stargazers", gc.gistController.GetGistStargazers)
+	router.GET("/:gistId", middleware.OptionalDeserializeUser(), gc.gistController.GetGistById)
+	router.GET("/:gistId/comments", middleware.OptionalDeserializeUser(), gc.gistController.GetGistComments)
+	router.GET("/:gistId/line-comments", middleware.OptionalDeserializeUser(), gc.gistController.GetGistLineComments)
+	router.GET("/:gistId/stargazers", middleware.OptionalDeserializeUser(), gc.gistController.GetGistStargazers)
+	router.GET("/:gistId/revisions", middleware.OptionalDeserializeUser(), gc.gistController.GetGistRevisions)
+	router.GET("/:gistId/revisions/:rev", middleware.OptionalDeserializeUser(), gc.gistController.GetGistRevision)
+	router.GET("/:gistId/compare/:revisionRange", middleware.OptionalDeserializeUser(), gc.gistController.CompareGistRevisions)
+	router.GET("/:gistId/forks", middleware.OptionalDeserializeUser(), gc.gistController.GetGistForks)
+	router.GET("/:gistId/events", middleware.OptionalDeserializeUser(), gc.gistController.StreamGistEvents)
+	router.GET("/:gistId/reactions", middleware.OptionalDeserializeUser(), gc.gistController.GetGistReactions)
+	router.GET("/:gistId/comments/:commentId/reactions", middleware.OptionalDeserializeUser(), gc.gistController.GetCommentReactions)
+	router.GET("/:gistId/edit", middleware.DeserializeUser(), gc.gistController.EditGistLive)
+
+	router.POST("/:gistId/fork", middleware.DeserializeUser(), gc.gistController.ForkGist)
+	router.POST("/:gistId/reactions", middleware.DeserializeUser(), gc.gistController.AddGistReaction)
+	router.POST("/:gistId/comments/:commentId/reactions", middleware.DeserializeUser(), gc.gistController.AddCommentReaction)
+
+	router.PATCH("/:gistId/comments/:commentId", middleware.DeserializeUser(), gc.gistController.UpdateComment)
+	router.DELETE("/:gistId/comments/:commentId", middleware.DeserializeUser(), gc.gistController.DeleteComment)
+	router.DELETE("/:gistId/reactions/:content", middleware.DeserializeUser(), gc.gistController.RemoveGistReaction)
+	router.DELETE("/:gistId/comments/:commentId/reactions/:content", middleware.DeserializeUser(), gc.gistController.RemoveCommentReaction)
 }
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/controllers"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type SearchRouteController struct {
+	searchController controllers.SearchController
+}
+
+func NewSearchRouteController(searchController controllers.SearchController) SearchRouteController {
+	return SearchRouteController{searchController: searchController}
+}
+
+func (sc *SearchRouteController) SearchRoute(rg *gin.RouterGroup) {
+	router := rg.Group("search")
+	router.GET("/gists", middleware.OptionalDeserializeUser(), sc.searchController.SearchGists)
+	router.GET("/code", middleware.OptionalDeserializeUser(), sc.searchController.SearchCode)
+	router.GET("/users", sc.searchController.SearchUsers)
+}
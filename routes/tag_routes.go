@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/controllers"
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type TagRouteController struct {
+	tagController controllers.TagController
+}
+
+func NewTagRouteController(tagController controllers.TagController) TagRouteController {
+	return TagRouteController{tagController: tagController}
+}
+
+func (tc *TagRouteController) TagRoute(rg *gin.RouterGroup) {
+	router := rg.Group("tags")
+	router.GET("", middleware.OptionalDeserializeUser(), tc.tagController.GetTags)
+	router.GET("/:tag/gists", middleware.OptionalDeserializeUser(), tc.tagController.GetTagGists)
+}
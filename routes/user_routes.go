@@ -18,12 +18,20 @@ func (uc *UserRouteController) UserRoute(rg *gin.RouterGroup) {
 	router := rg.Group("users")
 
 	router.GET("/me", middleware.DeserializeUser(), uc.userController.GetMe)
+	router.GET("/me/trash", middleware.DeserializeUser(), uc.userController.GetTrashedGists)
+	router.GET("/me/notifications", middleware.DeserializeUser(), uc.userController.GetNotifications)
+	router.GET("/me/email-preferences", middleware.DeserializeUser(), uc.userController.GetEmailPreferences)
+	router.GET("/me/feed", middleware.DeserializeUser(), uc.userController.GetFeed)
+	router.GET("/unsubscribe", uc.userController.ConfirmUnsubscribe)
 	router.GET("/:username", uc.userController.GetUser)
-	router.GET("/:username/gists", uc.userController.GetUserGists)
-	router.GET("/:username/gistIds", uc.userController.GetUserGistsIds)
+	router.GET("/:username/gists", middleware.OptionalDeserializeUser(), uc.userController.GetUserGists)
+	router.GET("/:username/gistIds", middleware.OptionalDeserializeUser(), uc.userController.GetUserGistsIds)
+	router.GET("/gists/:gistId/shares", middleware.DeserializeUser(), uc.userController.GetGistShares)
+	router.GET("/gists/:gistId/collaborators", middleware.DeserializeUser(), uc.userController.GetGistCollaborators)
 
 	router.POST("/gists", middleware.DeserializeUser(), uc.userController.CreateGist)
 	router.POST("/comments", middleware.DeserializeUser(), uc.userController.CreateCommentOnGist)
+	router.POST("/unsubscribe", uc.userController.Unsubscribe)
 
 	router.PATCH("/details", middleware.DeserializeUser(), uc.userController.UpdateUserDetails)
 	router.PATCH("/gists", middleware.DeserializeUser(), uc.userController.UpdateGist)
@@ -31,10 +39,21 @@ func (uc *UserRouteController) UserRoute(rg *gin.RouterGroup) {
 	router.PATCH("unfollow/:userToUnfollow", middleware.DeserializeUser(), uc.userController.UnfollowUser)
 	router.PATCH("gists/:gistId/star", middleware.DeserializeUser(), uc.userController.StarGist)
 	router.PATCH("gists/:gistId/unstar", middleware.DeserializeUser(), uc.userController.UnstarGist)
+	router.PATCH("gists/:gistId/share/:username", middleware.DeserializeUser(), uc.userController.ShareGist)
+	router.PATCH("gists/:gistId/unshare/:username", middleware.DeserializeUser(), uc.userController.UnshareGist)
+	router.PATCH("gists/:gistId/collaborators/:username", middleware.DeserializeUser(), uc.userController.AddGistCollaborator)
+	router.PATCH("gists/:gistId/restore", middleware.DeserializeUser(), uc.userController.RestoreGist)
+	router.PATCH("me/notifications/read", middleware.DeserializeUser(), uc.userController.MarkAllNotificationsRead)
+	router.PATCH("me/notifications/:notificationId/read", middleware.DeserializeUser(), uc.userController.MarkNotificationRead)
+	router.PATCH("me/email-preferences", middleware.DeserializeUser(), uc.userController.UpdateEmailPreferences)
+
+	router.DELETE("gists/:gistId", middleware.DeserializeUser(), uc.userController.DeleteGist)
+	router.DELETE("gists/:gistId/purge", middleware.DeserializeUser(), uc.userController.PurgeGist)
+	router.DELETE("gists/:gistId/collaborators/:username", middleware.DeserializeUser(), uc.userController.RemoveGistCollaborator)
 
 	router.GET("/:username/followers", uc.userController.GetFollowerList)
 	router.GET("/:username/following", uc.userController.GetFollowingList)
-	router.GET("/:username/starredGists", uc.userController.GetStarredGists)
+	router.GET("/:username/starredGists", middleware.OptionalDeserializeUser(), uc.userController.GetStarredGists)
 	router.GET("/:username/follows/:otherUser", uc.userController.CheckIfUserFollows)
 	router.GET("/:username/starredGist/:gistId", uc.userController.CheckIfGistStarred)
 }
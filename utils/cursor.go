@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// PageCursor points at the row a page of a sorted list ends with, links to the previous page point at the row it
+// starts with instead. Value is the sort value of the row and Key the unique value breaking ties between rows with the
+// same sort value.
+type PageCursor struct {
+	Sort      string `json:"s"`
+	Direction string `json:"d"`
+	Value     string `json:"v"`
+	Key       string `json:"k"`
+	Previous  bool   `json:"p,omitempty"`
+}
+
+func EncodePageCursor(cursor PageCursor) string {
+	encodedCursor, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encodedCursor)
+}
+
+func DecodePageCursor(cursor string) (PageCursor, error) {
+	var decodedCursor PageCursor
+
+	encodedCursor, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return decodedCursor, errInvalidCursor
+	}
+	if err := json.Unmarshal(encodedCursor, &decodedCursor); err != nil {
+		return decodedCursor, errInvalidCursor
+	}
+
+	return decodedCursor, nil
+}
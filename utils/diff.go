@@ -0,0 +1,300 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Vyom-Yadav/GitHub-Gist-Clone-Backend/models"
+)
+
+const (
+	DiffContext = "context"
+	DiffAdd     = "add"
+	DiffDelete  = "delete"
+)
+
+// DefaultDiffContextLines is the number of unchanged lines shown around every change, same as `diff -u`
+const DefaultDiffContextLines = 3
+
+// SplitLines splits text into lines, a trailing newline does not produce an empty last line
+func SplitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// MaxDiffEdits bounds the number of changed lines a diff is computed for. Past it the whole text is reported as
+// replaced, the cost of the diff grows with the number of changes times the number of lines.
+const MaxDiffEdits = 1000
+
+// diffLines computes the shortest edit script between a and b using the linear space variant of the Myers algorithm.
+// See http://www.xmailserver.org/diff2.pdf. If more than MaxDiffEdits lines changed, every line of a is deleted and
+// every line of b added, and false is returned.
+func diffLines(a, b []string) ([]models.DiffLine, bool) {
+	// The backward search is centered on the diagonal delta = len(a) - len(b) of the compared halves
+	offset := 2*(len(a)+len(b)) + 2
+	diff := &myersDiff{
+		a:        a,
+		b:        b,
+		deleted:  make([]bool, len(a)),
+		inserted: make([]bool, len(b)),
+		forward:  make([]int, 2*offset+1),
+		backward: make([]int, 2*offset+1),
+		offset:   offset,
+	}
+
+	ok := diff.compare(0, len(a), 0, len(b))
+	if !ok {
+		for i := range diff.deleted {
+			diff.deleted[i] = true
+		}
+		for j := range diff.inserted {
+			diff.inserted[j] = true
+		}
+	}
+
+	lines := make([]models.DiffLine, 0, len(a)+len(b))
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		switch {
+		case i < len(a) && diff.deleted[i]:
+			lines = append(lines, models.DiffLine{Type: DiffDelete, Content: a[i], OldLine: i + 1})
+			i++
+		case j < len(b) && diff.inserted[j]:
+			lines = append(lines, models.DiffLine{Type: DiffAdd, Content: b[j], NewLine: j + 1})
+			j++
+		default:
+			lines = append(lines, models.DiffLine{Type: DiffContext, Content: a[i], OldLine: i + 1, NewLine: j + 1})
+			i++
+			j++
+		}
+	}
+	return lines, ok
+}
+
+// myersDiff marks the deleted lines of a and the inserted lines of b. forward and backward hold the furthest x reached
+// on every diagonal k = x - y (shifted by offset), they are shared by the recursive calls which overwrite them.
+type myersDiff struct {
+	a, b              []string
+	deleted, inserted []bool
+	forward, backward []int
+	offset            int
+}
+
+// compare diffs a[xStart:xEnd] and b[yStart:yEnd], it returns false if there are more than MaxDiffEdits changes
+func (d *myersDiff) compare(xStart, xEnd, yStart, yEnd int) bool {
+	// Common prefixes and suffixes are unchanged
+	for xStart < xEnd && yStart < yEnd && d.a[xStart] == d.b[yStart] {
+		xStart++
+		yStart++
+	}
+	for xStart < xEnd && yStart < yEnd && d.a[xEnd-1] == d.b[yEnd-1] {
+		xEnd--
+		yEnd--
+	}
+
+	switch {
+	case xStart == xEnd:
+		for j := yStart; j < yEnd; j++ {
+			d.inserted[j] = true
+		}
+		return true
+	case yStart == yEnd:
+		for i := xStart; i < xEnd; i++ {
+			d.deleted[i] = true
+		}
+		return true
+	}
+
+	// Both halves of the split have at least one change, since the first and last lines differ
+	x, y, ok := d.middleSnake(xStart, xEnd, yStart, yEnd)
+	if !ok {
+		return false
+	}
+	return d.compare(xStart, x, yStart, y) && d.compare(x, xEnd, y, yEnd)
+}
+
+// middleSnake returns a point of a shortest edit script of a[xStart:xEnd] and b[yStart:yEnd] splitting it in two
+// halves with as many changes, found by searching forward from the start and backward from the end at the same time
+func (d *myersDiff) middleSnake(xStart, xEnd, yStart, yEnd int) (int, int, bool) {
+	n, m := xEnd-xStart, yEnd-yStart
+	delta := n - m
+	odd := delta%2 != 0
+
+	// Diagonals are relative to (xStart, yStart), the backward search starts on diagonal delta
+	forward, backward, o := d.forward, d.backward, d.offset
+	forward[o+1] = 0
+	backward[o+delta-1] = n
+
+	for edits := 0; edits <= (MaxDiffEdits+1)/2; edits++ {
+		for k := -edits; k <= edits; k += 2 {
+			var x int
+			if k == -edits || (k != edits && forward[o+k-1] < forward[o+k+1]) {
+				x = forward[o+k+1]
+			} else {
+				x = forward[o+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[xStart+x] == d.b[yStart+y] {
+				x++
+				y++
+			}
+			forward[o+k] = x
+
+			if odd && k >= delta-(edits-1) && k <= delta+(edits-1) && backward[o+k] <= x {
+				return xStart + x, yStart + y, true
+			}
+		}
+
+		for k := delta - edits; k <= delta+edits; k += 2 {
+			var x int
+			if k == delta+edits || (k != delta-edits && backward[o+k-1] < backward[o+k+1]) {
+				x = backward[o+k-1]
+			} else {
+				x = backward[o+k+1] - 1
+			}
+			y := x - k
+			for x > 0 && y > 0 && d.a[xStart+x-1] == d.b[yStart+y-1] {
+				x--
+				y--
+			}
+			backward[o+k] = x
+
+			if !odd && k >= -edits && k <= edits && x <= forward[o+k] {
+				return xStart + x, yStart + y, true
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// MapLines maps the (1 based) line numbers of the unchanged lines of oldText to their line numbers in newText. If more
+// than MaxDiffEdits lines changed no line is mapped and false is returned.
+func MapLines(oldText, newText string) (map[int]int, bool) {
+	lines, ok := diffLines(SplitLines(oldText), SplitLines(newText))
+	if !ok {
+		return nil, false
+	}
+
+	lineMap := make(map[int]int)
+	for _, line := range lines {
+		if line.Type == DiffContext {
+			lineMap[line.OldLine] = line.NewLine
+		}
+	}
+	return lineMap, true
+}
+
+// DiffHunks returns the hunks of changed lines between oldText and newText, each change is surrounded with
+// contextLines unchanged lines. Changes closer than 2*contextLines are merged in a single hunk. Past MaxDiffEdits
+// changes a single hunk replaces the whole text.
+func DiffHunks(oldText, newText string, contextLines int) []models.DiffHunk {
+	lines, _ := diffLines(SplitLines(oldText), SplitLines(newText))
+
+	hunks := make([]models.DiffHunk, 0)
+	for i := 0; i < len(lines); {
+		if lines[i].Type == DiffContext {
+			i++
+			continue
+		}
+
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+
+		// Extend the hunk as long as the next change is close enough
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].Type != DiffContext {
+				end = j
+			} else if j-end > 2*contextLines {
+				break
+			}
+		}
+		end += contextLines
+		if end >= len(lines) {
+			end = len(lines) - 1
+		}
+
+		hunks = append(hunks, newDiffHunk(lines, start, end))
+		i = end + 1
+	}
+
+	return hunks
+}
+
+func newDiffHunk(lines []models.DiffLine, start, end int) models.DiffHunk {
+	hunk := models.DiffHunk{Lines: lines[start : end+1]}
+
+	// Number of lines on either side preceding the hunk
+	oldBefore, newBefore := 0, 0
+	for _, line := range lines[:start] {
+		if line.Type != DiffAdd {
+			oldBefore++
+		}
+		if line.Type != DiffDelete {
+			newBefore++
+		}
+	}
+
+	for _, line := range hunk.Lines {
+		if line.Type != DiffAdd {
+			hunk.OldLines++
+		}
+		if line.Type != DiffDelete {
+			hunk.NewLines++
+		}
+	}
+
+	// An empty side points to the line after which the change happens
+	hunk.OldStart = oldBefore + 1
+	if hunk.OldLines == 0 {
+		hunk.OldStart = oldBefore
+	}
+	hunk.NewStart = newBefore + 1
+	if hunk.NewLines == 0 {
+		hunk.NewStart = newBefore
+	}
+
+	return hunk
+}
+
+// FormatUnifiedDiff renders hunks in the unified diff format, oldName or newName should be "/dev/null" for added or
+// deleted files
+func FormatUnifiedDiff(oldName, newName string, hunks []models.DiffHunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("--- " + oldName + "\n")
+	builder.WriteString("+++ " + newName + "\n")
+
+	for _, hunk := range hunks {
+		builder.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			formatHunkRange(hunk.OldStart, hunk.OldLines), formatHunkRange(hunk.NewStart, hunk.NewLines)))
+
+		for _, line := range hunk.Lines {
+			switch line.Type {
+			case DiffAdd:
+				builder.WriteString("+")
+			case DiffDelete:
+				builder.WriteString("-")
+			default:
+				builder.WriteString(" ")
+			}
+			builder.WriteString(line.Content + "\n")
+		}
+	}
+
+	return builder.String()
+}
+
+func formatHunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiffHunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldText  string
+		newText  string
+		expected string
+	}{
+		{
+			name: "both empty",
+		},
+		{
+			name:     "empty old text",
+			newText:  "a\nb\n",
+			expected: "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:     "empty new text",
+			oldText:  "a\nb\n",
+			expected: "@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:    "identical",
+			oldText: "a\nb\nc\n",
+			newText: "a\nb\nc\n",
+		},
+		{
+			name:    "missing trailing newline is not a change",
+			oldText: "a\nb",
+			newText: "a\nb\n",
+		},
+		{
+			name:     "change without trailing newline",
+			oldText:  "a\nb",
+			newText:  "a\nc",
+			expected: "@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+		},
+		{
+			name:     "context around a change",
+			oldText:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			newText:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			expected: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name:     "close changes are merged",
+			oldText:  "1\n2\n3\n4\n5\n6\n7\n8\n",
+			newText:  "one\n2\n3\n4\n5\n6\n7\neight\n",
+			expected: "@@ -1,8 +1,8 @@\n-1\n+one\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+eight\n",
+		},
+		{
+			name:    "distant changes are split",
+			oldText: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			newText: "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\neleven\n",
+			expected: "@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -8,4 +8,4 @@\n 8\n 9\n 10\n-11\n+eleven\n",
+		},
+		{
+			name:     "insertion",
+			oldText:  "a\nc\n",
+			newText:  "a\nb\nc\n",
+			expected: "@@ -1,2 +1,3 @@\n a\n+b\n c\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hunks := DiffHunks(test.oldText, test.newText, DefaultDiffContextLines)
+			diff := strings.TrimPrefix(FormatUnifiedDiff("old", "new", hunks), "--- old\n+++ new\n")
+			if diff != test.expected {
+				t.Errorf("expected diff:\n%s\ngot:\n%s", test.expected, diff)
+			}
+		})
+	}
+}
+
+func TestDiffHunksReplacesTooManyChanges(t *testing.T) {
+	oldLines := make([]string, MaxDiffEdits)
+	newLines := make([]string, MaxDiffEdits)
+	for i := range oldLines {
+		oldLines[i] = "old " + strconv.Itoa(i)
+		newLines[i] = "new " + strconv.Itoa(i)
+	}
+	// A shared line in the middle is not reported as unchanged once the limit is reached
+	oldLines[MaxDiffEdits/2] = "shared"
+	newLines[MaxDiffEdits/2] = "shared"
+
+	hunks := DiffHunks(strings.Join(oldLines, "\n"), strings.Join(newLines, "\n"), DefaultDiffContextLines)
+	if len(hunks) != 1 {
+		t.Fatalf("expected a single hunk, got %d", len(hunks))
+	}
+	hunk := hunks[0]
+	if hunk.OldStart != 1 || hunk.OldLines != MaxDiffEdits || hunk.NewStart != 1 || hunk.NewLines != MaxDiffEdits {
+		t.Errorf("expected the whole text to be replaced, got -%d,%d +%d,%d",
+			hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+	}
+	for _, line := range hunk.Lines {
+		if line.Type == DiffContext {
+			t.Fatalf("expected no unchanged line, got %q", line.Content)
+		}
+	}
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	tests := []struct {
+		oldText string
+		newText string
+		edits   int
+	}{
+		{oldText: "abcabba", newText: "cbabac", edits: 5},
+		{oldText: "abc", newText: "xyz", edits: 6},
+		{oldText: "aaaa", newText: "aa", edits: 2},
+		{oldText: "abcdef", newText: "fedcba", edits: 10},
+		{oldText: "xaxbxc", newText: "abc", edits: 3},
+	}
+
+	for _, test := range tests {
+		oldLines, newLines := strings.Split(test.oldText, ""), strings.Split(test.newText, "")
+		lines, ok := diffLines(oldLines, newLines)
+		if !ok {
+			t.Fatalf("%s -> %s: unexpected edit limit", test.oldText, test.newText)
+		}
+
+		var oldResult, newResult strings.Builder
+		edits := 0
+		for _, line := range lines {
+			if line.Type != DiffAdd {
+				oldResult.WriteString(line.Content)
+			}
+			if line.Type != DiffDelete {
+				newResult.WriteString(line.Content)
+			}
+			if line.Type != DiffContext {
+				edits++
+			}
+		}
+
+		if oldResult.String() != test.oldText || newResult.String() != test.newText {
+			t.Errorf("%s -> %s: the diff rebuilds %s -> %s", test.oldText, test.newText, oldResult.String(), newResult.String())
+		}
+		if edits != test.edits {
+			t.Errorf("%s -> %s: expected %d edits, got %d", test.oldText, test.newText, test.edits, edits)
+		}
+	}
+}
+
+func TestMapLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldText  string
+		newText  string
+		expected map[int]int
+	}{
+		{
+			name:     "identical",
+			oldText:  "a\nb\nc\n",
+			newText:  "a\nb\nc\n",
+			expected: map[int]int{1: 1, 2: 2, 3: 3},
+		},
+		{
+			name:     "inserted lines",
+			oldText:  "a\nb\nc\n",
+			newText:  "new\na\nb\nnew\nc\n",
+			expected: map[int]int{1: 2, 2: 3, 3: 5},
+		},
+		{
+			name:     "deleted lines",
+			oldText:  "a\nb\nc\nd\n",
+			newText:  "b\nd\n",
+			expected: map[int]int{2: 1, 4: 2},
+		},
+		{
+			name:     "changed line",
+			oldText:  "a\nb\nc\n",
+			newText:  "a\nB\nc\n",
+			expected: map[int]int{1: 1, 3: 3},
+		},
+		{
+			name:     "moved line is not mapped",
+			oldText:  "a\nb\nc\nd\n",
+			newText:  "b\nc\nd\na\n",
+			expected: map[int]int{2: 1, 3: 2, 4: 3},
+		},
+		{
+			name:     "empty new text",
+			oldText:  "a\nb\n",
+			expected: map[int]int{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lineMap, ok := MapLines(test.oldText, test.newText)
+			if !ok {
+				t.Fatal("unexpected edit limit")
+			}
+			if len(lineMap) != len(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, lineMap)
+			}
+			for oldLine, newLine := range test.expected {
+				if lineMap[oldLine] != newLine {
+					t.Fatalf("expected %v, got %v", test.expected, lineMap)
+				}
+			}
+		})
+	}
+}
+
+func TestMapLinesTooManyChanges(t *testing.T) {
+	var oldText, newText strings.Builder
+	for i := 0; i <= MaxDiffEdits; i++ {
+		oldText.WriteString("old " + strconv.Itoa(i) + "\nshared\n")
+		newText.WriteString("new " + strconv.Itoa(i) + "\nshared\n")
+	}
+
+	lineMap, ok := MapLines(oldText.String(), newText.String())
+	if ok || lineMap != nil {
+		t.Errorf("expected no line map past %d changes, got %d lines", MaxDiffEdits, len(lineMap))
+	}
+}
@@ -17,6 +17,33 @@ type EmailData struct {
 	URL       string
 	FirstName string
 	Subject   string
+
+	// Only used by notification emails
+	Message        string
+	UnsubscribeURL string
+
+	// Only used by digest emails
+	Digest *DigestData
+}
+
+// DigestData is the activity since the last digest email of a user
+type DigestData struct {
+	NewFollowers []string
+	Stars        []DigestItem
+	Comments     []DigestItem
+	NewGists     []DigestItem
+}
+
+// DigestItem : Username is the user who starred, commented or created the gist
+type DigestItem struct {
+	Username string
+	GistName string
+	Content  string
+	URL      string
+}
+
+func (d *DigestData) IsEmpty() bool {
+	return len(d.NewFollowers) == 0 && len(d.Stars) == 0 && len(d.Comments) == 0 && len(d.NewGists) == 0
 }
 
 func ParseTemplateDir(dir string) (*template.Template, error) {
@@ -56,12 +83,20 @@ func SendEmail(userEmail string, data *EmailData, emailTemp string) error {
 
 	var body bytes.Buffer
 
-	template, err := ParseTemplateDir(os.Getenv("GIST_EMAIL_TEMPLATE_DIR"))
+	templateDir := os.Getenv("GIST_EMAIL_TEMPLATE_DIR")
+	template, err := ParseTemplateDir(templateDir)
 	if err != nil {
 		zap.L().Error("could not parse template directory ", zap.Error(err))
 		return err
 	}
 
+	// Every email template defines "content", parse the requested one again so that its definition is the one used
+	template, err = template.ParseFiles(filepath.Join(templateDir, emailTemp))
+	if err != nil {
+		zap.L().Error("could not parse template ", zap.Error(err))
+		return err
+	}
+
 	err = template.ExecuteTemplate(&body, emailTemp, &data)
 	if err != nil {
 		zap.L().Error("Could not execute template ", zap.Error(err))
@@ -73,6 +108,11 @@ func SendEmail(userEmail string, data *EmailData, emailTemp string) error {
 	m.SetHeader("From", from)
 	m.SetHeader("To", to)
 	m.SetHeader("Subject", data.Subject)
+	if data.UnsubscribeURL != "" {
+		// See https://www.rfc-editor.org/rfc/rfc8058
+		m.SetHeader("List-Unsubscribe", "<"+data.UnsubscribeURL+">")
+		m.SetHeader("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
 	m.SetBody("text/html", body.String())
 	m.AddAlternative("text/plain", html2text.HTML2Text(body.String()))
 
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewETag creates a strong entity tag from the values identifying a version of a resource
+func NewETag(parts ...string) string {
+	return `"` + hashETagParts(parts...) + `"`
+}
+
+// NewVersionedETag creates an entity tag of a representation which also identifies the version of the content of the
+// resource. If-Match preconditions only compare the content version (see IfMatchVersion), the rest of the
+// representation can change independently of it, e.g. counters updated by other users.
+func NewVersionedETag(version string, parts ...string) string {
+	return `"` + hashETagParts(version) + "-" + hashETagParts(parts...) + `"`
+}
+
+// IfMatchVersion reports whether the If-Match header allows modifying the resource at the given content version, entity
+// tags created by NewVersionedETag match when their content version does. Weak entity tags never match since If-Match
+// uses the strong comparison, see https://www.rfc-editor.org/rfc/rfc9110#section-13.1.1
+func IfMatchVersion(header, version string) bool {
+	versionHash := hashETagParts(version)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if !strings.HasPrefix(candidate, `"`) || !strings.HasSuffix(candidate, `"`) || len(candidate) < 2 {
+			continue
+		}
+		candidateVersion, _, _ := strings.Cut(candidate[1:len(candidate)-1], "-")
+		if candidateVersion == versionHash {
+			return true
+		}
+	}
+	return false
+}
+
+func hashETagParts(parts ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(hash[:16])
+}
+
+// NotModified sets the validators of the response and reports whether the representation cached by the client is still
+// current, the response status is then set to 304 Not Modified and nothing else must be written. lastModified is only
+// used when it is not zero. If-None-Match takes precedence over If-Modified-Since, see
+// https://www.rfc-editor.org/rfc/rfc9110#section-13.2.2
+func NotModified(ctx *gin.Context, etag string, lastModified time.Time) bool {
+	ctx.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		ctx.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	notModified := false
+	if ifNoneMatch := ctx.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		notModified = ifNoneMatchFailed(ifNoneMatch, etag)
+	} else if ifModifiedSince := ctx.GetHeader("If-Modified-Since"); ifModifiedSince != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		// HTTP dates have a precision of one second
+		notModified = err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+
+	if notModified {
+		ctx.Status(http.StatusNotModified)
+	}
+	return notModified
+}
+
+// ifNoneMatchFailed uses the weak comparison, W/"x" and "x" match
+func ifNoneMatchFailed(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCacheControl lets shared caches store public responses, responses depending on the authenticated user are only
+// stored by the cache of the user. Caches always revalidate with the validators set by NotModified before reusing a
+// response.
+func SetCacheControl(ctx *gin.Context, public bool) {
+	if public {
+		ctx.Header("Cache-Control", "public, no-cache")
+	} else {
+		ctx.Header("Cache-Control", "private, no-cache")
+	}
+	ctx.Header("Vary", "Authorization, Cookie")
+}
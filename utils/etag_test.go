@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	etag := NewVersionedETag("v1", "stars:1")
+	sameVersionETag := NewVersionedETag("v1", "stars:2")
+	otherVersionETag := NewVersionedETag("v2", "stars:1")
+
+	tests := []struct {
+		name    string
+		header  string
+		matches bool
+	}{
+		{name: "same entity tag", header: etag, matches: true},
+		{name: "same version with other counters", header: sameVersionETag, matches: true},
+		{name: "other version", header: otherVersionETag},
+		{name: "weak entity tag", header: "W/" + etag},
+		{name: "star", header: "*", matches: true},
+		{name: "star in a list", header: otherVersionETag + ", *", matches: true},
+		{name: "list containing the version", header: otherVersionETag + ",  " + etag, matches: true},
+		{name: "list of other versions", header: otherVersionETag + ", W/" + etag},
+		{name: "unquoted entity tag", header: etag[1 : len(etag)-1]},
+		{name: "lone quote", header: `"`},
+		{name: "empty", header: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if matches := IfMatchVersion(test.header, "v1"); matches != test.matches {
+				t.Errorf("expected %t for %s, got %t", test.matches, test.header, matches)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	etag := NewETag("v1")
+	lastModified := time.Date(2023, 5, 1, 12, 0, 0, 500, time.UTC)
+
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		notModified bool
+	}{
+		{name: "no validators"},
+		{name: "same entity tag", headers: map[string]string{"If-None-Match": etag}, notModified: true},
+		{name: "weak comparison", headers: map[string]string{"If-None-Match": "W/" + etag}, notModified: true},
+		{name: "star", headers: map[string]string{"If-None-Match": "*"}, notModified: true},
+		{name: "other entity tag", headers: map[string]string{"If-None-Match": NewETag("v2")}},
+		{
+			name:        "not modified since",
+			headers:     map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)},
+			notModified: true,
+		},
+		{
+			name:    "modified since",
+			headers: map[string]string{"If-Modified-Since": lastModified.Add(-time.Second).Format(http.TimeFormat)},
+		},
+		{
+			name: "entity tag takes precedence",
+			headers: map[string]string{
+				"If-None-Match":     NewETag("v2"),
+				"If-Modified-Since": lastModified.Format(http.TimeFormat),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			for name, value := range test.headers {
+				ctx.Request.Header.Set(name, value)
+			}
+
+			if notModified := NotModified(ctx, etag, lastModified); notModified != test.notModified {
+				t.Errorf("expected %t, got %t", test.notModified, notModified)
+			}
+			if recorder.Header().Get("ETag") != etag {
+				t.Errorf("expected the ETag header %s, got %s", etag, recorder.Header().Get("ETag"))
+			}
+			if recorder.Header().Get("Last-Modified") != lastModified.Format(http.TimeFormat) {
+				t.Errorf("expected the Last-Modified header, got %s", recorder.Header().Get("Last-Modified"))
+			}
+		})
+	}
+}
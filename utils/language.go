@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Files which are recognised by their complete name rather than the extension
+var languageByFilename = map[string]string{
+	"dockerfile":     "Dockerfile",
+	"makefile":       "Makefile",
+	"go.mod":         "Go Module",
+	"go.sum":         "Go Checksums",
+	"cmakelists.txt": "CMake",
+	"gemfile":        "Ruby",
+	"rakefile":       "Ruby",
+	"jenkinsfile":    "Groovy",
+	"vagrantfile":    "Ruby",
+}
+
+var languageByExtension = map[string]string{
+	".go":         "Go",
+	".py":         "Python",
+	".js":         "JavaScript",
+	".mjs":        "JavaScript",
+	".cjs":        "JavaScript",
+	".jsx":        "JavaScript",
+	".ts":         "TypeScript",
+	".tsx":        "TypeScript",
+	".java":       "Java",
+	".kt":         "Kotlin",
+	".kts":        "Kotlin",
+	".scala":      "Scala",
+	".groovy":     "Groovy",
+	".c":          "C",
+	".h":          "C",
+	".cc":         "C++",
+	".cpp":        "C++",
+	".cxx":        "C++",
+	".hpp":        "C++",
+	".cs":         "C#",
+	".rs":         "Rust",
+	".rb":         "Ruby",
+	".php":        "PHP",
+	".swift":      "Swift",
+	".m":          "Objective-C",
+	".dart":       "Dart",
+	".lua":        "Lua",
+	".pl":         "Perl",
+	".r":          "R",
+	".hs":         "Haskell",
+	".ex":         "Elixir",
+	".exs":        "Elixir",
+	".erl":        "Erlang",
+	".clj":        "Clojure",
+	".sh":         "Shell",
+	".bash":       "Shell",
+	".zsh":        "Shell",
+	".fish":       "Fish",
+	".ps1":        "PowerShell",
+	".bat":        "Batchfile",
+	".sql":        "SQL",
+	".html":       "HTML",
+	".htm":        "HTML",
+	".css":        "CSS",
+	".scss":       "SCSS",
+	".sass":       "Sass",
+	".less":       "Less",
+	".vue":        "Vue",
+	".svelte":     "Svelte",
+	".json":       "JSON",
+	".yaml":       "YAML",
+	".yml":        "YAML",
+	".toml":       "TOML",
+	".xml":        "XML",
+	".ini":        "INI",
+	".env":        "Dotenv",
+	".properties": "Java Properties",
+	".md":         "Markdown",
+	".markdown":   "Markdown",
+	".rst":        "reStructuredText",
+	".tex":        "TeX",
+	".tf":         "HCL",
+	".hcl":        "HCL",
+	".proto":      "Protocol Buffer",
+	".graphql":    "GraphQL",
+	".dockerfile": "Dockerfile",
+	".mk":         "Makefile",
+	".txt":        "Text",
+}
+
+// DetectLanguage guesses the language of a gist file from its name, an empty string is returned
+// when the language is not known
+func DetectLanguage(filename string) string {
+	base := strings.ToLower(filepath.Base(filename))
+	if language, ok := languageByFilename[base]; ok {
+		return language
+	}
+
+	return languageByExtension[filepath.Ext(base)]
+}
@@ -0,0 +1,21 @@
+package utils
+
+import "regexp"
+
+// A mention is an @ followed by a username, the @ must not be preceded by a word character so that email addresses
+// like someone@example.com are not treated as mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9][A-Za-z0-9_-]*)`)
+
+// ParseMentions returns the usernames mentioned in text, in order of first appearance and without duplicates
+func ParseMentions(text string) []string {
+	usernames := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		username := match[1]
+		if !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
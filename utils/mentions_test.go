@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{text: "", expected: []string{}},
+		{text: "no mentions here", expected: []string{}},
+		{text: "@alice", expected: []string{"alice"}},
+		{text: "thanks @alice and @bob!", expected: []string{"alice", "bob"}},
+		{text: "@bob @alice @bob", expected: []string{"bob", "alice"}},
+		{text: "(cc @alice)", expected: []string{"alice"}},
+		{text: "first line\n@alice", expected: []string{"alice"}},
+		{text: "@user_name-1.", expected: []string{"user_name-1"}},
+		{text: "mail someone@example.com", expected: []string{}},
+		{text: "@@alice", expected: []string{}},
+		{text: "@_alice @-bob", expected: []string{}},
+		{text: "@ alice", expected: []string{}},
+		{text: "@Alice @alice", expected: []string{"Alice", "alice"}},
+	}
+
+	for _, test := range tests {
+		mentions := ParseMentions(test.text)
+		if mentions == nil {
+			t.Errorf("%q: expected an empty slice, got nil", test.text)
+		}
+		if strings.Join(mentions, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%q: expected %v, got %v", test.text, test.expected, mentions)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"unicode/utf16"
+)
+
+// TextOperation is an operational transform operation on a text document, compatible with ot.js. Lengths and positions
+// are counted in UTF-16 code units like in JavaScript strings, documents are therefore handled as []uint16.
+//
+// In JSON an operation is an array of components: a positive number retains (skips) that many characters, a negative
+// number deletes that many characters and a string is inserted.
+type TextOperation struct {
+	components   []textComponent
+	baseLength   int
+	targetLength int
+}
+
+// textComponent : exactly one of the fields is set
+type textComponent struct {
+	retain int
+	insert []uint16
+	delete int
+}
+
+var ErrOperationLength = errors.New("the operation does not match the length of the document")
+
+func EncodeText(text string) []uint16 {
+	return utf16.Encode([]rune(text))
+}
+
+func DecodeText(text []uint16) string {
+	return string(utf16.Decode(text))
+}
+
+// BaseLength is the length of the documents the operation can be applied to
+func (o *TextOperation) BaseLength() int {
+	return o.baseLength
+}
+
+// TargetLength is the length of the document after applying the operation
+func (o *TextOperation) TargetLength() int {
+	return o.targetLength
+}
+
+func (o *TextOperation) Retain(n int) {
+	if n <= 0 {
+		return
+	}
+	o.baseLength += n
+	o.targetLength += n
+
+	if last := len(o.components) - 1; last >= 0 && o.components[last].retain > 0 {
+		o.components[last].retain += n
+		return
+	}
+	o.components = append(o.components, textComponent{retain: n})
+}
+
+func (o *TextOperation) Insert(text []uint16) {
+	if len(text) == 0 {
+		return
+	}
+	o.targetLength += len(text)
+
+	last := len(o.components) - 1
+	switch {
+	case last >= 0 && o.components[last].insert != nil:
+		o.components[last].insert = append(o.components[last].insert, text...)
+	case last >= 0 && o.components[last].delete > 0:
+		// Inserts always go before deletes, it does not matter for the result and keeps operations canonical
+		if last >= 1 && o.components[last-1].insert != nil {
+			o.components[last-1].insert = append(o.components[last-1].insert, text...)
+		} else {
+			deleteComponent := o.components[last]
+			o.components[last] = textComponent{insert: append([]uint16{}, text...)}
+			o.components = append(o.components, deleteComponent)
+		}
+	default:
+		o.components = append(o.components, textComponent{insert: append([]uint16{}, text...)})
+	}
+}
+
+func (o *TextOperation) Delete(n int) {
+	if n <= 0 {
+		return
+	}
+	o.baseLength += n
+
+	if last := len(o.components) - 1; last >= 0 && o.components[last].delete > 0 {
+		o.components[last].delete += n
+		return
+	}
+	o.components = append(o.components, textComponent{delete: n})
+}
+
+// Apply returns the document after applying the operation to it
+func (o *TextOperation) Apply(document []uint16) ([]uint16, error) {
+	if len(document) != o.baseLength {
+		return nil, ErrOperationLength
+	}
+
+	result := make([]uint16, 0, o.targetLength)
+	position := 0
+	for _, component := range o.components {
+		switch {
+		case component.retain > 0:
+			result = append(result, document[position:position+component.retain]...)
+			position += component.retain
+		case component.insert != nil:
+			result = append(result, component.insert...)
+		default:
+			position += component.delete
+		}
+	}
+
+	return result, nil
+}
+
+// TransformIndex moves a position in the document (e.g. a cursor) so that it points at the same character after the
+// operation is applied
+func (o *TextOperation) TransformIndex(index int) int {
+	newIndex := index
+	for _, component := range o.components {
+		switch {
+		case component.retain > 0:
+			index -= component.retain
+		case component.insert != nil:
+			newIndex += len(component.insert)
+		default:
+			if index < component.delete {
+				newIndex -= index
+			} else {
+				newIndex -= component.delete
+			}
+			index -= component.delete
+		}
+		if index < 0 {
+			break
+		}
+	}
+	return newIndex
+}
+
+// TransformOperations transforms two concurrent operations a and b on the same document into a' and b' such that
+// applying a then b' gives the same document as applying b then a'. When both insert at the same position the insert
+// of a goes first.
+func TransformOperations(a, b *TextOperation) (*TextOperation, *TextOperation, error) {
+	if a.baseLength != b.baseLength {
+		return nil, nil, ErrOperationLength
+	}
+
+	aPrime, bPrime := &TextOperation{}, &TextOperation{}
+	aComponents, bComponents := a.components, b.components
+
+	// The components currently being consumed, partially consumed ones are shortened in place
+	var aComponent, bComponent *textComponent
+	next := func(components *[]textComponent) *textComponent {
+		if len(*components) == 0 {
+			return nil
+		}
+		component := (*components)[0]
+		*components = (*components)[1:]
+		return &component
+	}
+	aComponent, bComponent = next(&aComponents), next(&bComponents)
+
+	for aComponent != nil || bComponent != nil {
+		if aComponent != nil && aComponent.insert != nil {
+			aPrime.Insert(aComponent.insert)
+			bPrime.Retain(len(aComponent.insert))
+			aComponent = next(&aComponents)
+			continue
+		}
+		if bComponent != nil && bComponent.insert != nil {
+			aPrime.Retain(len(bComponent.insert))
+			bPrime.Insert(bComponent.insert)
+			bComponent = next(&bComponents)
+			continue
+		}
+		if aComponent == nil || bComponent == nil {
+			return nil, nil, ErrOperationLength
+		}
+
+		aLength, bLength := aComponent.retain+aComponent.delete, bComponent.retain+bComponent.delete
+		length := aLength
+		if bLength < length {
+			length = bLength
+		}
+
+		switch {
+		case aComponent.retain > 0 && bComponent.retain > 0:
+			aPrime.Retain(length)
+			bPrime.Retain(length)
+		case aComponent.delete > 0 && bComponent.retain > 0:
+			aPrime.Delete(length)
+		case aComponent.retain > 0 && bComponent.delete > 0:
+			bPrime.Delete(length)
+		}
+		// Both deleting the same characters produces nothing
+
+		aComponent = consume(aComponent, length, func() *textComponent { return next(&aComponents) })
+		bComponent = consume(bComponent, length, func() *textComponent { return next(&bComponents) })
+	}
+
+	return aPrime, bPrime, nil
+}
+
+// consume shortens a retain or delete component by length and moves on to the next component once it is used up
+func consume(component *textComponent, length int, next func() *textComponent) *textComponent {
+	if component.retain > 0 {
+		component.retain -= length
+		if component.retain == 0 {
+			return next()
+		}
+		return component
+	}
+
+	component.delete -= length
+	if component.delete == 0 {
+		return next()
+	}
+	return component
+}
+
+func (o TextOperation) MarshalJSON() ([]byte, error) {
+	components := make([]interface{}, 0, len(o.components))
+	for _, component := range o.components {
+		switch {
+		case component.retain > 0:
+			components = append(components, component.retain)
+		case component.insert != nil:
+			components = append(components, DecodeText(component.insert))
+		default:
+			components = append(components, -component.delete)
+		}
+	}
+	return json.Marshal(components)
+}
+
+func (o *TextOperation) UnmarshalJSON(data []byte) error {
+	var rawComponents []json.RawMessage
+	if err := json.Unmarshal(data, &rawComponents); err != nil {
+		return err
+	}
+
+	*o = TextOperation{}
+	for _, rawComponent := range rawComponents {
+		if bytes.HasPrefix(bytes.TrimSpace(rawComponent), []byte(`"`)) {
+			var text string
+			if err := json.Unmarshal(rawComponent, &text); err != nil {
+				return err
+			}
+			if text == "" {
+				return errors.New("invalid operation: empty insert")
+			}
+			o.Insert(EncodeText(text))
+			continue
+		}
+
+		var n int
+		if err := json.Unmarshal(rawComponent, &n); err != nil {
+			return errors.New("invalid operation: components must be integers or strings")
+		}
+		switch {
+		case n > 0:
+			o.Retain(n)
+		case n < 0:
+			o.Delete(-n)
+		default:
+			return errors.New("invalid operation: zero length component")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func parseOperation(t *testing.T, operation string) *TextOperation {
+	t.Helper()
+
+	var parsed TextOperation
+	if err := json.Unmarshal([]byte(operation), &parsed); err != nil {
+		t.Fatalf("invalid operation %s: %v", operation, err)
+	}
+	return &parsed
+}
+
+func applyOperation(t *testing.T, operation *TextOperation, document string) string {
+	t.Helper()
+
+	result, err := operation.Apply(EncodeText(document))
+	if err != nil {
+		t.Fatalf("cannot apply the operation to %q: %v", document, err)
+	}
+	return DecodeText(result)
+}
+
+func TestTransformOperationsConverges(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		a        string
+		b        string
+		expected string
+	}{
+		{
+			name:     "inserts at different positions",
+			document: "hello world",
+			a:        `[5, ",", 6]`,
+			b:        `[11, "!"]`,
+			expected: "hello, world!",
+		},
+		{
+			name:     "inserts at the same position, a first",
+			document: "ac",
+			a:        `[1, "x", 1]`,
+			b:        `[1, "y", 1]`,
+			expected: "axyc",
+		},
+		{
+			name:     "overlapping deletes",
+			document: "abcdef",
+			a:        `[1, -3, 2]`,
+			b:        `[2, -3, 1]`,
+			expected: "af",
+		},
+		{
+			name:     "same delete",
+			document: "abc",
+			a:        `[1, -1, 1]`,
+			b:        `[1, -1, 1]`,
+			expected: "ac",
+		},
+		{
+			name:     "insert inside a deleted range",
+			document: "abcd",
+			a:        `[1, -2, 1]`,
+			b:        `[2, "x", 2]`,
+			expected: "axd",
+		},
+		{
+			name:     "replace against insert",
+			document: "abc",
+			a:        `[-3, "xyz"]`,
+			b:        `[3, "!"]`,
+			expected: "xyz!",
+		},
+		{
+			name:     "empty document",
+			document: "",
+			a:        `["a"]`,
+			b:        `["b"]`,
+			expected: "ab",
+		},
+		{
+			name:     "characters outside the BMP",
+			document: "a😀b",
+			a:        `[1, -2, 1]`,
+			b:        `[3, "é", 1]`,
+			expected: "aéb",
+		},
+		{
+			name:     "inserts after characters outside the BMP",
+			document: "😀😀",
+			a:        `[2, "x", 2]`,
+			b:        `[4, "y"]`,
+			expected: "😀x😀y",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := parseOperation(t, test.a), parseOperation(t, test.b)
+			aPrime, bPrime, err := TransformOperations(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			afterA := applyOperation(t, bPrime, applyOperation(t, a, test.document))
+			afterB := applyOperation(t, aPrime, applyOperation(t, b, test.document))
+			if afterA != afterB {
+				t.Fatalf("the documents diverge: %q and %q", afterA, afterB)
+			}
+			if afterA != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, afterA)
+			}
+		})
+	}
+}
+
+func TestTransformOperationsLengthMismatch(t *testing.T) {
+	_, _, err := TransformOperations(parseOperation(t, `[3]`), parseOperation(t, `[2, "x"]`))
+	if !errors.Is(err, ErrOperationLength) {
+		t.Errorf("expected ErrOperationLength, got %v", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		document  string
+		operation string
+		expected  string
+		err       error
+	}{
+		{name: "retain", document: "abc", operation: `[3]`, expected: "abc"},
+		{name: "insert", document: "abc", operation: `[1, "xy", 2]`, expected: "axybc"},
+		{name: "delete", document: "abc", operation: `[1, -2]`, expected: "a"},
+		{name: "into empty document", document: "", operation: `["abc"]`, expected: "abc"},
+		{name: "delete a surrogate pair", document: "a😀b", operation: `[1, -2, 1]`, expected: "ab"},
+		{name: "too short", document: "abcd", operation: `[3]`, err: ErrOperationLength},
+		{name: "too long", document: "ab", operation: `[3]`, err: ErrOperationLength},
+		{name: "lengths count UTF-16 units", document: "😀", operation: `[1]`, err: ErrOperationLength},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := parseOperation(t, test.operation).Apply(EncodeText(test.document))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+			if err == nil && DecodeText(result) != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, DecodeText(result))
+			}
+		})
+	}
+}
+
+func TestTransformIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation string
+		index     int
+		expected  int
+	}{
+		{name: "insert before", operation: `[1, "xy", 3]`, index: 2, expected: 4},
+		{name: "insert at the index", operation: `[2, "xy", 2]`, index: 2, expected: 4},
+		{name: "insert after", operation: `[3, "xy", 1]`, index: 2, expected: 2},
+		{name: "delete before", operation: `[-2, 2]`, index: 3, expected: 1},
+		{name: "delete around", operation: `[1, -2, 1]`, index: 2, expected: 1},
+		{name: "delete after", operation: `[3, -1]`, index: 2, expected: 2},
+		{name: "surrogate pair inserted before", operation: `["😀", 2]`, index: 1, expected: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			index := parseOperation(t, test.operation).TransformIndex(test.index)
+			if index != test.expected {
+				t.Errorf("expected %d, got %d", test.expected, index)
+			}
+		})
+	}
+}
+
+func TestTextOperationJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "components", input: `[2,"abc",-1,4]`, expected: `[2,"abc",-1,4]`},
+		{name: "merged components", input: `[1,1,"a","b",-1,-2]`, expected: `[2,"ab",-3]`},
+		{name: "insert moved before delete", input: `[-1,"a"]`, expected: `["a",-1]`},
+		{name: "characters outside the BMP", input: `["😀é"]`, expected: `["😀é"]`},
+		{name: "empty", input: `[]`, expected: `[]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			operation := parseOperation(t, test.input)
+			encoded, err := json.Marshal(operation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(encoded) != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, encoded)
+			}
+
+			roundTripped := parseOperation(t, string(encoded))
+			if roundTripped.BaseLength() != operation.BaseLength() || roundTripped.TargetLength() != operation.TargetLength() {
+				t.Errorf("lengths changed after a round trip: %d -> %d became %d -> %d", operation.BaseLength(),
+					operation.TargetLength(), roundTripped.BaseLength(), roundTripped.TargetLength())
+			}
+		})
+	}
+
+	invalid := []string{`[0]`, `[""]`, `[1.5]`, `[true]`, `{}`}
+	for _, input := range invalid {
+		var operation TextOperation
+		if err := json.Unmarshal([]byte(input), &operation); err == nil {
+			t.Errorf("expected %s to be rejected", input)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+var (
+	errInvalidUnsubscribeToken  = errors.New("invalid unsubscribe token")
+	errMissingUnsubscribeSecret = errors.New("UNSUBSCRIBE_SECRET is not set, unsubscribe links cannot be signed")
+)
+
+// SignUnsubscribeToken creates the token of an unsubscribe link, the token identifies the user and the kind of emails to
+// stop and does not expire. Without a secret the link could never be verified, an error is returned instead.
+func SignUnsubscribeToken(secret, username, kind string) (string, error) {
+	if secret == "" {
+		return "", errMissingUnsubscribeSecret
+	}
+	payload := base64.RawURLEncoding.EncodeToString([]byte(username + ":" + kind))
+	return payload + "." + signUnsubscribePayload(secret, payload), nil
+}
+
+// ParseUnsubscribeToken verifies a token created with SignUnsubscribeToken and returns the username and kind
+func ParseUnsubscribeToken(secret, token string) (string, string, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found || secret == "" {
+		return "", "", errInvalidUnsubscribeToken
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(signUnsubscribePayload(secret, payload))) {
+		return "", "", errInvalidUnsubscribeToken
+	}
+
+	decodedPayload, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", errInvalidUnsubscribeToken
+	}
+
+	// Kinds never contain a colon, usernames might
+	separator := strings.LastIndex(string(decodedPayload), ":")
+	if separator == -1 {
+		return "", "", errInvalidUnsubscribeToken
+	}
+	return string(decodedPayload[:separator]), string(decodedPayload[separator+1:]), nil
+}
+
+func signUnsubscribePayload(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnsubscribeTokenRoundTrip(t *testing.T) {
+	tests := []struct {
+		username string
+		kind     string
+	}{
+		{username: "alice", kind: "notifications"},
+		{username: "bob", kind: "digest"},
+		{username: "user:with:colons", kind: "digest"},
+	}
+
+	for _, test := range tests {
+		token, err := SignUnsubscribeToken("secret", test.username, test.kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		username, kind, err := ParseUnsubscribeToken("secret", token)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", test.username, test.kind, err)
+		}
+		if username != test.username || kind != test.kind {
+			t.Errorf("expected %s/%s, got %s/%s", test.username, test.kind, username, kind)
+		}
+	}
+}
+
+func TestUnsubscribeTokenWithoutSecret(t *testing.T) {
+	_, err := SignUnsubscribeToken("", "alice", "digest")
+	if !errors.Is(err, errMissingUnsubscribeSecret) {
+		t.Errorf("expected errMissingUnsubscribeSecret, got %v", err)
+	}
+
+	token, err := SignUnsubscribeToken("secret", "alice", "digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = ParseUnsubscribeToken("", token)
+	if !errors.Is(err, errInvalidUnsubscribeToken) {
+		t.Errorf("expected errInvalidUnsubscribeToken, got %v", err)
+	}
+}
+
+func TestUnsubscribeTokenTampering(t *testing.T) {
+	token, err := SignUnsubscribeToken("secret", "alice", "digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+
+	otherPayload := base64.RawURLEncoding.EncodeToString([]byte("mallory:digest"))
+	otherToken, err := SignUnsubscribeToken("secret", "mallory", "digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherSignature, _ := strings.Cut(otherToken, ".")
+
+	tests := []struct {
+		name   string
+		secret string
+		token  string
+	}{
+		{name: "other secret", secret: "other secret", token: token},
+		{name: "changed payload", secret: "secret", token: otherPayload + "." + signature},
+		{name: "signature of another payload", secret: "secret", token: payload + "." + otherSignature},
+		{name: "truncated signature", secret: "secret", token: payload + "." + signature[:len(signature)-1]},
+		{name: "missing signature", secret: "secret", token: payload},
+		{name: "empty signature", secret: "secret", token: payload + "."},
+		{name: "empty token", secret: "secret", token: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := ParseUnsubscribeToken(test.secret, test.token)
+			if !errors.Is(err, errInvalidUnsubscribeToken) {
+				t.Errorf("expected errInvalidUnsubscribeToken, got %v", err)
+			}
+		})
+	}
+}